@@ -0,0 +1,105 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the returned channel once d has passed
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the system clock in the given location
+type Real struct {
+	Location *time.Location
+}
+
+// Now returns the current system time, in Location when set
+func (c Real) Now() time.Time {
+	if c.Location == nil {
+		return time.Now()
+	}
+	return time.Now().In(c.Location)
+}
+
+// After waits for d on the system clock
+func (c Real) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Fake is a manually controlled clock for deterministic tests
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+// waiter is a pending After of a fake clock
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFake creates a fake clock stopped at the given time
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the time the fake clock is set to
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After fires once the fake clock is moved d past the current time, at once
+// when d isn't positive
+func (c *Fake) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), ch: ch})
+	c.fire()
+	return ch
+}
+
+// Waiters returns how many Afters haven't fired yet, so a test can wait until
+// the code under test is waiting before moving the clock
+func (c *Fake) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Set moves the fake clock to the given time
+func (c *Fake) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+	c.fire()
+}
+
+// Advance moves the fake clock forward by d
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// fire sends the time to the waiters that are due and drops them
+func (c *Fake) fire() {
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
@@ -0,0 +1,45 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeAfter(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c := NewFake(start)
+
+	hour := c.After(time.Hour)
+	now := c.After(0)
+	select {
+	case got := <-now:
+		if !got.Equal(start) {
+			t.Errorf("After(0) sent %v, want %v", got, start)
+		}
+	default:
+		t.Error("After(0) didn't fire at once")
+	}
+
+	c.Advance(59 * time.Minute)
+	select {
+	case <-hour:
+		t.Fatal("After(1h) fired after 59m")
+	default:
+	}
+	if n := c.Waiters(); n != 1 {
+		t.Errorf("Waiters() = %d, want 1", n)
+	}
+
+	c.Set(start.Add(2 * time.Hour))
+	select {
+	case got := <-hour:
+		if !got.Equal(start.Add(2 * time.Hour)) {
+			t.Errorf("After(1h) sent %v, want the time the clock was set to", got)
+		}
+	default:
+		t.Error("After(1h) didn't fire once the clock passed it")
+	}
+	if n := c.Waiters(); n != 0 {
+		t.Errorf("Waiters() = %d, want 0", n)
+	}
+}
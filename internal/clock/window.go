@@ -0,0 +1,80 @@
+package clock
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time range like 23:00-07:00, which may wrap past midnight.
+// Start and End are offsets from midnight.
+type Window struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseWindow parses a range in the form "HH:MM-HH:MM"
+func ParseWindow(s string) (*Window, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid time window %q, expected HH:MM-HH:MM", s)
+	}
+
+	start, err := parseTimeOfDay(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	end, err := parseTimeOfDay(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("invalid time window %q: start and end are equal", s)
+	}
+
+	return &Window{Start: start, End: end}, nil
+}
+
+// parseTimeOfDay parses "HH:MM" into an offset from midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t, in its own location, falls inside the window
+func (w Window) Contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Remaining returns how long from t until the window next ends
+func (w Window) Remaining(t time.Time) time.Duration {
+	end := midnight(t).Add(w.End)
+	if !end.After(t) {
+		end = midnight(t).AddDate(0, 0, 1).Add(w.End)
+	}
+	return end.Sub(t)
+}
+
+// String formats the window back as "HH:MM-HH:MM"
+func (w Window) String() string {
+	return formatTimeOfDay(w.Start) + "-" + formatTimeOfDay(w.End)
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return t.Sub(midnight(t))
+}
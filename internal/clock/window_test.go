@@ -0,0 +1,46 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowContains(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 15, hour, minute, 0, 0, moscow)
+	}
+
+	tests := []struct {
+		window string
+		time   time.Time
+		want   bool
+	}{
+		{"09:00-18:00", at(9, 0), true},
+		{"09:00-18:00", at(12, 30), true},
+		{"09:00-18:00", at(17, 59), true},
+		{"09:00-18:00", at(18, 0), false},
+		{"09:00-18:00", at(8, 59), false},
+		{"23:00-07:00", at(23, 0), true},
+		{"23:00-07:00", at(0, 0), true},
+		{"23:00-07:00", at(6, 59), true},
+		{"23:00-07:00", at(7, 0), false},
+		{"23:00-07:00", at(22, 59), false},
+		{"23:00-07:00", at(12, 0), false},
+		{"00:00-01:00", at(0, 0), true},
+		{"00:00-01:00", at(23, 59), false},
+		// The hour is taken in the time's own location, 21:30 UTC is 00:30 MSK
+		{"00:00-01:00", time.Date(2024, 3, 14, 21, 30, 0, 0, time.UTC).In(moscow), true},
+		{"00:00-01:00", time.Date(2024, 3, 14, 21, 30, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		window, err := ParseWindow(tt.window)
+		if err != nil {
+			t.Fatalf("ParseWindow(%q) error = %v", tt.window, err)
+		}
+		if got := window.Contains(tt.time); got != tt.want {
+			t.Errorf("Window(%s).Contains(%s) = %v, want %v", tt.window, tt.time.Format("15:04 MST"), got, tt.want)
+		}
+	}
+}
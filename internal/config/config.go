@@ -1,18 +1,27 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"avito-parser/internal/clock"
+
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Redis   RedisConfig
-	Browser BrowserConfig
-	Parser  ParserConfig
-	Avito   AvitoConfig
+	Redis     RedisConfig
+	FileStore FileStoreConfig
+	SQLite    SQLiteConfig
+	Storage   StorageConfig
+	Browser   BrowserConfig
+	Parser    ParserConfig
+	Avito     AvitoConfig
 }
 
 type RedisConfig struct {
@@ -20,40 +29,211 @@ type RedisConfig struct {
 	Port     string
 	Password string
 	DB       int
+	Category string
+	Compress bool
+	Verbose  bool
+	// OpTimeout bounds a single Redis call, 0 means no limit
+	OpTimeout time.Duration
+	// ConnectRetries is how many more times connecting is tried at startup,
+	// waiting ConnectBackoff and then twice as long after each failure
+	ConnectRetries int
+	ConnectBackoff time.Duration
+}
+
+type FileStoreConfig struct {
+	Dir string
+}
+
+type SQLiteConfig struct {
+	Path string
+}
+
+// StorageConfig lists the stores listings are written to, the first one is primary.
+// DryRun reads from them but writes nothing.
+type StorageConfig struct {
+	Backends []string
+	DryRun   bool
 }
 
 type BrowserConfig struct {
-	Headless bool
-	Timeout  time.Duration
+	Headless    bool
+	Timeout     time.Duration
+	Warmup      bool
+	Locale      string
+	Profile     string
+	UserDataDir string
+	RevealPhone bool
+	Lifetime    string
+
+	Proxies             []string
+	ProxyMaxFailures    int
+	ProxyRotateEachPage bool
 }
 
 type ParserConfig struct {
 	DelayBetweenRequests time.Duration
 	CycleDelay           time.Duration
 	PageDelay            time.Duration
+	DelayDistribution    string
+	DelaySpread          time.Duration
+	DelayFloor           time.Duration
+	DelaySeed            int64
+	ElementWait          time.Duration
+	ContentWait          time.Duration
+	ProbeWait            time.Duration
+	RetryDelay           time.Duration
+	SelectorTolerance    int
+	SelectorOverrides    map[string][]string
+	MaxRuntime           time.Duration
+	PprofAddr            string
+	IDStrategy           string
+	MinTitleLength       int
+	RejectTitles         []string
+	RequirePriceDigits   bool
+	MinPrice             int64
+	MaxPrice             int64
+	FXRates              map[string]float64
+	BufferSize           int
+	SaveMaxAttempts      int
+	SeenCacheSize        int
+	MaxStored            int
+	BlockKeywords        []string
+	BodyScanLimit        int
+	BlockAlertThreshold  float64
+	BlockAlertWindow     int
+	BlockAlertCooldown   time.Duration
+	NoResultsSelector    string
+	NoResultsText        string
+	SkipArchived         bool
+	RequireTags          []string
+	ExcludeTags          []string
+	CycleRetryBudget     int
+	CycleTimeout         time.Duration
+	MaxScrolls           int
+	MaxElements          int
+	SortByDate           bool
+	MaxAge               time.Duration
+	KeepUnknownAge       bool
+	NewWindow            time.Duration
+	IncludeLocations     []string
+	ExcludeLocations     []string
+	IncludeMetro         []string
+	KeepUnknownLocation  bool
+	RequireImages        bool
+	Location             *time.Location
+	QuietHours           *clock.Window
+	ImageDir             string
+	RepostWindow         time.Duration
+	Watchlist            []string
+	SnapshotDir          string
+	SnapshotRetention    time.Duration
+	CycleCommand         []string
+	MetricsFile          string
+	StoreFields          []string
+	RawHTMLDir           string
+	RawHTMLMaxFiles      int
+	RawHTMLRetention     time.Duration
 }
 
+// AvitoConfig holds the searches to parse. BaseURLs has several entries when
+// cities are parsed side by side, at most Concurrency of them at once.
 type AvitoConfig struct {
-	BaseURL string
+	BaseURL     string
+	BaseURLs    []string
+	Concurrency int
 }
 
-// Load loads configuration from environment variables
+// Load loads configuration from environment variables, falling back to the
+// values of CONFIG_FILE when one is set
 func Load() (*Config, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 
+	// Fill variables left unset from the config file
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		err := loadConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CONFIG_FILE: %w", err)
+		}
+	}
+
 	// Parse Redis DB
 	redisDB, err := strconv.Atoi(getEnv("REDIS_DB", "0"))
 	if err != nil {
 		redisDB = 0
 	}
 
+	// Parse max Redis DB index
+	redisMaxDB, err := strconv.Atoi(getEnv("REDIS_MAX_DB", "15"))
+	if err != nil {
+		redisMaxDB = 15
+	}
+
+	// Select Redis DB for the category if mapped
+	category := getEnv("AVITO_CATEGORY", "")
+	categoryDBs, err := parseCategoryDBs(getEnv("REDIS_CATEGORY_DBS", ""))
+	if err != nil {
+		return nil, err
+	}
+	if categoryDB, ok := categoryDBs[category]; ok && category != "" {
+		redisDB = categoryDB
+	}
+
+	if redisDB < 0 || redisDB > redisMaxDB {
+		return nil, fmt.Errorf("redis DB index %d is out of range 0-%d", redisDB, redisMaxDB)
+	}
+
+	// Parse value compression
+	compressValues, err := strconv.ParseBool(getEnv("COMPRESS_VALUES", "false"))
+	if err != nil {
+		compressValues = false
+	}
+
+	redisVerbose, err := strconv.ParseBool(getEnv("REDIS_VERBOSE", "false"))
+	if err != nil {
+		redisVerbose = false
+	}
+
+	// Parse the per-call Redis timeout
+	redisOpTimeout, err := time.ParseDuration(getEnv("REDIS_OP_TIMEOUT", "5s"))
+	if err != nil || redisOpTimeout < 0 {
+		redisOpTimeout = 5 * time.Second
+	}
+
+	// Parse how long to wait for Redis at startup
+	redisConnectRetries, err := strconv.Atoi(getEnv("REDIS_CONNECT_RETRIES", "5"))
+	if err != nil || redisConnectRetries < 0 {
+		redisConnectRetries = 5
+	}
+	redisConnectBackoff, err := time.ParseDuration(getEnv("REDIS_CONNECT_BACKOFF", "1s"))
+	if err != nil || redisConnectBackoff <= 0 {
+		redisConnectBackoff = time.Second
+	}
+
 	// Parse headless mode
 	headless, err := strconv.ParseBool(getEnv("HEADLESS", "true"))
 	if err != nil {
 		headless = true
 	}
 
+	// Parse warm-up mode
+	warmup, err := strconv.ParseBool(getEnv("WARMUP", "false"))
+	if err != nil {
+		warmup = false
+	}
+
+	// Parse phone reveal on listing pages
+	revealPhone, err := strconv.ParseBool(getEnv("REVEAL_PHONE", "false"))
+	if err != nil {
+		revealPhone = false
+	}
+
+	// Parse browser lifetime policy
+	lifetime := getEnv("BROWSER_LIFETIME", "persistent")
+	if lifetime != "persistent" && lifetime != "per-cycle" {
+		return nil, fmt.Errorf("invalid BROWSER_LIFETIME %q, expected persistent or per-cycle", lifetime)
+	}
+
 	// Parse timeout
 	timeoutSeconds, err := strconv.Atoi(getEnv("TIMEOUT", "30"))
 	if err != nil {
@@ -78,30 +258,438 @@ func Load() (*Config, error) {
 		pageDelaySeconds = 2
 	}
 
+	// Parse page delay distribution
+	delaySpreadMs, err := strconv.Atoi(getEnv("DELAY_SPREAD_MS", "1000"))
+	if err != nil {
+		delaySpreadMs = 1000
+	}
+
+	delayFloorMs, err := strconv.Atoi(getEnv("DELAY_MIN_MS", "500"))
+	if err != nil {
+		delayFloorMs = 500
+	}
+
+	delaySeed, err := strconv.ParseInt(getEnv("DELAY_SEED", "0"), 10, 64)
+	if err != nil {
+		delaySeed = 0
+	}
+
+	// Parse element wait
+	elementWaitMs, err := strconv.Atoi(getEnv("ELEMENT_WAIT_MS", "1000"))
+	if err != nil {
+		elementWaitMs = 1000
+	}
+
+	// Parse the caps on waiting for search results to render
+	contentWait, err := time.ParseDuration(getEnv("CONTENT_WAIT", "3s"))
+	if err != nil || contentWait < 0 {
+		contentWait = 3 * time.Second
+	}
+
+	probeWait, err := time.ParseDuration(getEnv("PROBE_WAIT", "2s"))
+	if err != nil || probeWait < 0 {
+		probeWait = 2 * time.Second
+	}
+
+	// Parse the pause before a failed search page is tried again
+	retryDelay, err := time.ParseDuration(getEnv("RETRY_DELAY", "2s"))
+	if err != nil || retryDelay < 0 {
+		retryDelay = 2 * time.Second
+	}
+
+	// Parse how many times a failing listing selector is retried before the page is given up
+	selectorTolerance, err := strconv.Atoi(getEnv("SELECTOR_ERROR_TOLERANCE", "2"))
+	if err != nil || selectorTolerance < 0 {
+		selectorTolerance = 2
+	}
+
+	// Parse max runtime (0 means run forever)
+	maxRuntimeSeconds, err := strconv.Atoi(getEnv("MAX_RUNTIME", "0"))
+	if err != nil {
+		maxRuntimeSeconds = 0
+	}
+
+	// Parse listing quality thresholds
+	minTitleLength, err := strconv.Atoi(getEnv("MIN_TITLE_LENGTH", "5"))
+	if err != nil {
+		minTitleLength = 5
+	}
+
+	requirePriceDigits, err := strconv.ParseBool(getEnv("REQUIRE_PRICE_DIGITS", "true"))
+	if err != nil {
+		requirePriceDigits = true
+	}
+
+	// Parse plausible price bounds
+	minPrice, err := strconv.ParseInt(getEnv("MIN_PRICE", "1000"), 10, 64)
+	if err != nil {
+		minPrice = 1000
+	}
+
+	maxPrice, err := strconv.ParseInt(getEnv("MAX_PRICE", "10000000"), 10, 64)
+	if err != nil {
+		maxPrice = 10000000
+	}
+
+	// Parse currency rates, e.g. USD=92.5,EUR=100
+	fxRates, err := parseFXRates(getEnv("FX_RATES", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse buffer size for Redis outages
+	bufferSize, err := strconv.Atoi(getEnv("REDIS_BUFFER_SIZE", "1000"))
+	if err != nil {
+		bufferSize = 1000
+	}
+
+	// Parse how often a listing may fail to save before it is dead-lettered
+	saveMaxAttempts, err := strconv.Atoi(getEnv("SAVE_MAX_ATTEMPTS", "3"))
+	if err != nil || saveMaxAttempts < 0 {
+		saveMaxAttempts = 3
+	}
+
+	// Parse the size of the in-memory cache of saved listings
+	seenCacheSize, err := strconv.Atoi(getEnv("SEEN_CACHE_SIZE", "0"))
+	if err != nil || seenCacheSize < 0 {
+		seenCacheSize = 0
+	}
+
+	// Parse the cap on stored listings, the oldest are evicted beyond it
+	maxStored, err := strconv.Atoi(getEnv("MAX_STORED_LISTINGS", "0"))
+	if err != nil || maxStored < 0 {
+		maxStored = 0
+	}
+
+	// Parse how much page text block detection reads
+	bodyScanLimit, err := strconv.Atoi(getEnv("BODY_SCAN_LIMIT", "20000"))
+	if err != nil {
+		bodyScanLimit = 20000
+	}
+
+	// Parse the block rate alert, the threshold in percent like 50 or 50%
+	blockAlertThreshold, err := strconv.ParseFloat(strings.TrimSuffix(getEnv("BLOCK_ALERT_THRESHOLD", "0"), "%"), 64)
+	if err != nil || blockAlertThreshold < 0 || blockAlertThreshold > 100 {
+		blockAlertThreshold = 0
+	}
+
+	blockAlertWindow, err := strconv.Atoi(getEnv("BLOCK_ALERT_WINDOW", "20"))
+	if err != nil || blockAlertWindow <= 0 {
+		blockAlertWindow = 20
+	}
+
+	blockAlertCooldown, err := time.ParseDuration(getEnv("BLOCK_ALERT_COOLDOWN", "0"))
+	if err != nil || blockAlertCooldown < 0 {
+		blockAlertCooldown = 0
+	}
+
+	// Parse archived listings handling
+	skipArchived, err := strconv.ParseBool(getEnv("SKIP_ARCHIVED", "false"))
+	if err != nil {
+		skipArchived = false
+	}
+
+	// Parse cycle retry budget, 0 means no limit
+	cycleRetryBudget, err := strconv.Atoi(getEnv("CYCLE_RETRY_BUDGET", "10"))
+	if err != nil || cycleRetryBudget < 0 {
+		cycleRetryBudget = 10
+	}
+
+	// Parse the wall-clock limit of a cycle, 0 means no limit
+	cycleTimeout, err := time.ParseDuration(getEnv("CYCLE_TIMEOUT", "0"))
+	if err != nil || cycleTimeout < 0 {
+		cycleTimeout = 0
+	}
+
+	// Parse max scrolls for infinite-scroll layouts
+	maxScrolls, err := strconv.Atoi(getEnv("MAX_SCROLLS", "10"))
+	if err != nil {
+		maxScrolls = 10
+	}
+
+	// Parse the cap on matched cards per page
+	maxElements, err := strconv.Atoi(getEnv("MAX_ELEMENTS_PER_PAGE", "500"))
+	if err != nil || maxElements < 0 {
+		maxElements = 500
+	}
+
+	// Parse newest-first sorting of search pages
+	sortByDate, err := strconv.ParseBool(getEnv("SORT_BY_DATE", "false"))
+	if err != nil {
+		sortByDate = false
+	}
+
+	// Parse listing age filter, e.g. 72h
+	maxAge, err := time.ParseDuration(getEnv("MAX_AGE", "0"))
+	if err != nil {
+		maxAge = 0
+	}
+
+	keepUnknownAge, err := strconv.ParseBool(getEnv("KEEP_UNKNOWN_AGE", "true"))
+	if err != nil {
+		keepUnknownAge = true
+	}
+
+	// Parse the window in which a listing still counts as new, e.g. 30m
+	newWindow, err := time.ParseDuration(getEnv("NEW_LISTING_WINDOW", "0"))
+	if err != nil || newWindow < 0 {
+		newWindow = 0
+	}
+
+	// Parse handling of listings without a location under location filters
+	keepUnknownLocation, err := strconv.ParseBool(getEnv("KEEP_UNKNOWN_LOCATION", "true"))
+	if err != nil {
+		keepUnknownLocation = true
+	}
+
+	// Parse dropping of listings without photos
+	requireImages, err := strconv.ParseBool(getEnv("REQUIRE_IMAGES", "false"))
+	if err != nil {
+		requireImages = false
+	}
+
+	// Parse timezone of listing timestamps
+	timezone := getEnv("TIMEZONE", "Europe/Moscow")
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TIMEZONE %q: %w", timezone, err)
+	}
+
+	// Load the proxy pool, one http:// or socks5:// proxy per line
+	var proxies []string
+	if proxyListFile := getEnv("PROXY_LIST_FILE", ""); proxyListFile != "" {
+		proxies, err = readProxyList(proxyListFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	proxyMaxFailures, err := strconv.Atoi(getEnv("PROXY_MAX_FAILURES", "3"))
+	if err != nil {
+		proxyMaxFailures = 3
+	}
+
+	proxyRotateEachPage, err := strconv.ParseBool(getEnv("PROXY_ROTATE_EACH_PAGE", "false"))
+	if err != nil {
+		proxyRotateEachPage = false
+	}
+
+	// Parse snapshot retention, e.g. 168h
+	snapshotRetention, err := time.ParseDuration(getEnv("SNAPSHOT_RETENTION", "168h"))
+	if err != nil {
+		snapshotRetention = 7 * 24 * time.Hour
+	}
+
+	// Parse how long photos are remembered for repost detection, e.g. 168h
+	repostWindow, err := time.ParseDuration(getEnv("REPOST_WINDOW", "0"))
+	if err != nil || repostWindow < 0 {
+		repostWindow = 0
+	}
+
+	// Parse the searches parsed side by side, one per city
+	baseURLs := getEnvList("AVITO_URLS", "")
+	for _, baseURL := range baseURLs {
+		if err := ValidateAvitoURL(baseURL); err != nil {
+			return nil, fmt.Errorf("invalid AVITO_URLS: %w", err)
+		}
+	}
+
+	concurrency, err := strconv.Atoi(getEnv("MAX_CONCURRENT_CITIES", "2"))
+	if err != nil || concurrency < 1 {
+		concurrency = 2
+	}
+
+	// Load the watch list, one listing URL or numeric Avito id per line
+	var watchlist []string
+	if watchlistFile := getEnv("WATCHLIST_FILE", ""); watchlistFile != "" {
+		watchlist, err = readWatchlist(watchlistFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse raw HTML capture, kept in RAW_HTML_DIR only when enabled
+	storeRawHTML, err := strconv.ParseBool(getEnv("STORE_RAW_HTML", "false"))
+	if err != nil {
+		storeRawHTML = false
+	}
+
+	rawHTMLDir := ""
+	if storeRawHTML {
+		rawHTMLDir = getEnv("RAW_HTML_DIR", "raw_html")
+	}
+
+	rawHTMLMaxFiles, err := strconv.Atoi(getEnv("RAW_HTML_MAX_FILES", "200"))
+	if err != nil || rawHTMLMaxFiles < 0 {
+		rawHTMLMaxFiles = 200
+	}
+
+	rawHTMLRetention, err := time.ParseDuration(getEnv("RAW_HTML_RETENTION", "24h"))
+	if err != nil || rawHTMLRetention < 0 {
+		rawHTMLRetention = 24 * time.Hour
+	}
+
+	// Parse the profiling address, binding to localhost when no host is given
+	pprofAddr, err := parsePprofAddr(getEnv("PPROF_ADDR", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse storage backends, by default the file store when configured or Redis
+	fileStoreDir := getEnv("FILE_STORE_DIR", "")
+	sqlitePath := getEnv("SQLITE_PATH", "")
+	backends, err := parseStorageBackends(getEnvList("STORAGE_BACKENDS", ""), fileStoreDir, sqlitePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse dry run, which detects new and updated listings without saving them
+	dryRun, err := strconv.ParseBool(getEnv("DRY_RUN", "false"))
+	if err != nil {
+		dryRun = false
+	}
+
+	// Parse the daily pause, e.g. 23:00-07:00 in TIMEZONE
+	var quietHours *clock.Window
+	if value := getEnv("QUIET_HOURS", ""); value != "" {
+		quietHours, err = clock.ParseWindow(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QUIET_HOURS: %w", err)
+		}
+	}
+
 	config := &Config{
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
 			Port:     getEnv("REDIS_PORT", "6379"),
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       redisDB,
+			Category: category,
+			Compress: compressValues,
+			Verbose:  redisVerbose,
+
+			OpTimeout:      redisOpTimeout,
+			ConnectRetries: redisConnectRetries,
+			ConnectBackoff: redisConnectBackoff,
+		},
+		FileStore: FileStoreConfig{
+			Dir: fileStoreDir,
+		},
+		SQLite: SQLiteConfig{
+			Path: sqlitePath,
+		},
+		Storage: StorageConfig{
+			Backends: backends,
+			DryRun:   dryRun,
 		},
 		Browser: BrowserConfig{
 			Headless: headless,
 			Timeout:  time.Duration(timeoutSeconds) * time.Second,
+			Warmup:   warmup,
+			Locale:   getEnv("BROWSER_LOCALE", "ru-RU"),
+			Profile:  getEnv("BROWSER_PROFILE", ""),
+
+			UserDataDir: getEnv("USER_DATA_DIR", ""),
+			RevealPhone: revealPhone,
+			Lifetime:    lifetime,
+
+			Proxies:             proxies,
+			ProxyMaxFailures:    proxyMaxFailures,
+			ProxyRotateEachPage: proxyRotateEachPage,
 		},
 		Parser: ParserConfig{
 			DelayBetweenRequests: time.Duration(delaySeconds) * time.Second,
 			CycleDelay:           time.Duration(cycleDelaySeconds) * time.Second,
 			PageDelay:            time.Duration(pageDelaySeconds) * time.Second,
+			DelayDistribution:    getEnv("DELAY_DISTRIBUTION", "fixed"),
+			DelaySpread:          time.Duration(delaySpreadMs) * time.Millisecond,
+			DelayFloor:           time.Duration(delayFloorMs) * time.Millisecond,
+			DelaySeed:            delaySeed,
+			ElementWait:          time.Duration(elementWaitMs) * time.Millisecond,
+			ContentWait:          contentWait,
+			ProbeWait:            probeWait,
+			RetryDelay:           retryDelay,
+			SelectorTolerance:    selectorTolerance,
+			SelectorOverrides:    selectorOverrides(),
+			MaxRuntime:           time.Duration(maxRuntimeSeconds) * time.Second,
+			PprofAddr:            pprofAddr,
+			IDStrategy:           getEnv("ID_STRATEGY", "avito_id"),
+			MinTitleLength:       minTitleLength,
+			RejectTitles:         getEnvList("REJECT_TITLES", "Показать телефон,Написать,Избранное,Реклама,Подробнее"),
+			RequirePriceDigits:   requirePriceDigits,
+			MinPrice:             minPrice,
+			MaxPrice:             maxPrice,
+			FXRates:              fxRates,
+			BufferSize:           bufferSize,
+			SaveMaxAttempts:      saveMaxAttempts,
+			SeenCacheSize:        seenCacheSize,
+			MaxStored:            maxStored,
+			BlockKeywords:        getEnvList("BLOCK_KEYWORDS", "доступ ограничен,доступ временно ограничен,проблема с ip,блокировка,доступ запрещен,access denied,captcha,проверка браузера"),
+			BodyScanLimit:        bodyScanLimit,
+			BlockAlertThreshold:  blockAlertThreshold / 100,
+			BlockAlertWindow:     blockAlertWindow,
+			BlockAlertCooldown:   blockAlertCooldown,
+			NoResultsSelector:    getEnv("NO_RESULTS_SELECTOR", "[data-marker='search-empty-result']"),
+			NoResultsText:        getEnv("NO_RESULTS_TEXT", "ничего не найдено"),
+			SkipArchived:         skipArchived,
+			RequireTags:          getEnvList("REQUIRE_TAGS", ""),
+			ExcludeTags:          getEnvList("EXCLUDE_TAGS", ""),
+			CycleRetryBudget:     cycleRetryBudget,
+			CycleTimeout:         cycleTimeout,
+			MaxScrolls:           maxScrolls,
+			MaxElements:          maxElements,
+			SortByDate:           sortByDate,
+			MaxAge:               maxAge,
+			KeepUnknownAge:       keepUnknownAge,
+			NewWindow:            newWindow,
+			IncludeLocations:     getEnvList("INCLUDE_LOCATIONS", ""),
+			ExcludeLocations:     getEnvList("EXCLUDE_LOCATIONS", ""),
+			IncludeMetro:         getEnvList("INCLUDE_METRO", ""),
+			KeepUnknownLocation:  keepUnknownLocation,
+			RequireImages:        requireImages,
+			Location:             location,
+			QuietHours:           quietHours,
+			ImageDir:             getEnv("DOWNLOAD_IMAGES_DIR", ""),
+			RepostWindow:         repostWindow,
+			Watchlist:            watchlist,
+			SnapshotDir:          getEnv("SNAPSHOT_DIR", ""),
+			SnapshotRetention:    snapshotRetention,
+			CycleCommand:         strings.Fields(getEnv("ON_CYCLE_COMMAND", "")),
+			MetricsFile:          getEnv("METRICS_FILE", ""),
+			StoreFields:          getEnvList("STORE_FIELDS", ""),
+			RawHTMLDir:           rawHTMLDir,
+			RawHTMLMaxFiles:      rawHTMLMaxFiles,
+			RawHTMLRetention:     rawHTMLRetention,
 		},
 		Avito: AvitoConfig{
-			BaseURL: getEnv("AVITO_URL", "https://www.avito.ru/chelyabinsk/kvartiry/sdam/na_dlitelnyy_srok-ASgBAgICAkSSA8gQ8AeQUg?context=H4sIAAAAAAAA_wEjANz_YToxOntzOjg6ImZyb21QYWdlIjtzOjc6ImNhdGFsb2ciO312FITcIwAAAA&district=16"),
+			BaseURL:     getEnv("AVITO_URL", "https://www.avito.ru/chelyabinsk/kvartiry/sdam/na_dlitelnyy_srok-ASgBAgICAkSSA8gQ8AeQUg?context=H4sIAAAAAAAA_wEjANz_YToxOntzOjg6ImZyb21QYWdlIjtzOjc6ImNhdGFsb2ciO312FITcIwAAAA&district=16"),
+			BaseURLs:    baseURLs,
+			Concurrency: concurrency,
 		},
 	}
 
+	// The first city doubles as the base URL of single-search modes
+	if len(config.Avito.BaseURLs) > 0 {
+		config.Avito.BaseURL = config.Avito.BaseURLs[0]
+	}
+
 	return config, nil
 }
 
+// ValidateAvitoURL checks that the URL is an absolute link to avito.ru
+func ValidateAvitoURL(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	host := strings.ToLower(parsedURL.Hostname())
+	if host != "avito.ru" && !strings.HasSuffix(host, ".avito.ru") {
+		return fmt.Errorf("URL %q is not an avito.ru address", rawURL)
+	}
+	return nil
+}
+
 // getEnv gets environment variable with default value
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -109,4 +697,192 @@ func getEnv(key, defaultValue string) string {
 		return defaultValue
 	}
 	return value
-}
\ No newline at end of file
+}
+
+// getEnvList gets comma-separated environment variable values with default
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// selectorFields are the parser's selector sets that SELECTORS_<FIELD> extends
+var selectorFields = []string{"listing", "title", "price", "date", "location", "metro", "badge"}
+
+// selectorOverrides reads the comma-separated SELECTORS_<FIELD> variables
+func selectorOverrides() map[string][]string {
+	overrides := make(map[string][]string)
+	for _, field := range selectorFields {
+		selectors := getEnvList("SELECTORS_"+strings.ToUpper(field), "")
+		if len(selectors) > 0 {
+			overrides[field] = selectors
+		}
+	}
+	return overrides
+}
+
+// readProxyList reads proxies from a file, one per line; blank lines and # comments are skipped
+func readProxyList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PROXY_LIST_FILE: %w", err)
+	}
+
+	var proxies []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parsedURL, err := url.Parse(line)
+		if err != nil || parsedURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy on line %d of %s: %q", i+1, path, line)
+		}
+		switch parsedURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q on line %d of %s", parsedURL.Scheme, i+1, path)
+		}
+		proxies = append(proxies, line)
+	}
+	return proxies, nil
+}
+
+// readWatchlist reads watched listings from a file, one listing URL or numeric
+// Avito id per line; blank lines and # comments are skipped
+func readWatchlist(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WATCHLIST_FILE: %w", err)
+	}
+
+	var watchlist []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if _, err := strconv.ParseUint(line, 10, 64); err != nil {
+			parsedURL, err := url.Parse(line)
+			if err != nil || parsedURL.Host == "" {
+				return nil, fmt.Errorf("invalid watched listing on line %d of %s: %q", i+1, path, line)
+			}
+		}
+		watchlist = append(watchlist, line)
+	}
+	return watchlist, nil
+}
+
+// Storage backend names
+const (
+	BackendRedis  = "redis"
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+)
+
+// parseStorageBackends validates STORAGE_BACKENDS, defaulting to a single backend
+func parseStorageBackends(names []string, fileStoreDir, sqlitePath string) ([]string, error) {
+	if len(names) == 0 {
+		if fileStoreDir != "" {
+			return []string{BackendFile}, nil
+		}
+		return []string{BackendRedis}, nil
+	}
+
+	seen := make(map[string]bool)
+	backends := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		switch name {
+		case BackendRedis:
+		case BackendFile:
+			if fileStoreDir == "" {
+				return nil, fmt.Errorf("storage backend %q requires FILE_STORE_DIR", name)
+			}
+		case BackendSQLite:
+			if sqlitePath == "" {
+				return nil, fmt.Errorf("storage backend %q requires SQLITE_PATH", name)
+			}
+		default:
+			return nil, fmt.Errorf("unknown storage backend %q, expected redis, file or sqlite", name)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("storage backend %q is listed twice", name)
+		}
+		seen[name] = true
+		backends = append(backends, name)
+	}
+
+	return backends, nil
+}
+
+// parsePprofAddr validates PPROF_ADDR, turning a bare ":port" into a localhost address
+func parsePprofAddr(addr string) (string, error) {
+	if addr == "" {
+		return "", nil
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid PPROF_ADDR %q: %w", addr, err)
+	}
+
+	if host == "" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// parseFXRates parses comma-separated "currency=rate" pairs, rates in rubles per unit
+func parseFXRates(value string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	if strings.TrimSpace(value) == "" {
+		return rates, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid FX_RATES entry %q, expected currency=rate", pair)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate in FX_RATES entry %q", pair)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(parts[0]))] = rate
+	}
+
+	return rates, nil
+}
+
+// parseCategoryDBs parses comma-separated "category=db" pairs
+func parseCategoryDBs(value string) (map[string]int, error) {
+	result := make(map[string]int)
+	if strings.TrimSpace(value) == "" {
+		return result, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid category DB mapping %q, expected category=db", pair)
+		}
+
+		db, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid DB index in category mapping %q: %w", pair, err)
+		}
+		result[strings.TrimSpace(parts[0])] = db
+	}
+
+	return result, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePprofAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{addr: "", want: ""},
+		{addr: ":6060", want: "localhost:6060"},
+		{addr: "localhost:6060", want: "localhost:6060"},
+		{addr: "127.0.0.1:6060", want: "127.0.0.1:6060"},
+		{addr: "0.0.0.0:6060", want: "0.0.0.0:6060"},
+		{addr: "[::1]:6060", want: "[::1]:6060"},
+		{addr: "6060", wantErr: true},
+		{addr: "localhost", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePprofAddr(tt.addr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePprofAddr(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePprofAddr(%q) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestParseFXRates(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{value: "", want: map[string]float64{}},
+		{value: "USD=90", want: map[string]float64{"USD": 90}},
+		{value: " usd = 90.5 , EUR=98 ", want: map[string]float64{"USD": 90.5, "EUR": 98}},
+		{value: "USD", wantErr: true},
+		{value: "USD=abc", wantErr: true},
+		{value: "USD=0", wantErr: true},
+		{value: "USD=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseFXRates(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFXRates(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFXRates(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
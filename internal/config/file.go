@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// configKeyPattern matches the keys of a config file, the names of the
+// environment variables in any case
+var configKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// loadConfigFile reads a flat YAML or TOML file whose keys are the names of
+// the environment variables, e.g. "redis_host: redis" or REDIS_HOST = "redis".
+// Values only fill variables that are empty, so the environment and .env
+// override the file. Lists are written as [a, b] or as a comma-separated
+// string. Nested sections are not supported.
+func loadConfigFile(path string) error {
+	var separator, example string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		separator, example = ":", "KEY: value"
+	case ".toml":
+		separator, example = "=", "KEY = value"
+	default:
+		return fmt.Errorf("unsupported config file format %q, expected .yaml, .yml or .toml", filepath.Ext(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" || line == "---" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, separator)
+		key = strings.TrimSpace(key)
+		if !ok || !configKeyPattern.MatchString(key) {
+			return fmt.Errorf("line %d: expected %s, got %q", lineNumber, example, line)
+		}
+
+		value, err := parseConfigValue(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		values[strings.ToUpper(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		if os.Getenv(key) == "" {
+			os.Setenv(key, value)
+		}
+	}
+	return nil
+}
+
+// stripComment cuts a # comment off the line, leaving # inside quotes alone
+func stripComment(line string) string {
+	var quote rune
+	for i, char := range line {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseConfigValue unquotes a scalar and joins the items of a [a, b] list
+// with commas, the way list variables are written in the environment
+func parseConfigValue(value string) (string, error) {
+	if !strings.HasPrefix(value, "[") {
+		return unquoteConfigValue(value)
+	}
+	if !strings.HasSuffix(value, "]") {
+		return "", fmt.Errorf("unterminated list %q", value)
+	}
+
+	var items []string
+	for _, item := range strings.Split(value[1:len(value)-1], ",") {
+		item, err := unquoteConfigValue(strings.TrimSpace(item))
+		if err != nil {
+			return "", err
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return strings.Join(items, ","), nil
+}
+
+// unquoteConfigValue removes double quotes, resolving escapes, or single
+// quotes, taken literally
+func unquoteConfigValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid quoted value %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	}
+	return value, nil
+}
@@ -0,0 +1,286 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStripComment(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"redis_host: redis", "redis_host: redis"},
+		{"redis_host: redis # local", "redis_host: redis "},
+		{"# whole line", ""},
+		{`no_results_text: "ничего # не найдено"`, `no_results_text: "ничего # не найдено"`},
+		{`selector: '[data-marker="item"]' # cards`, `selector: '[data-marker="item"]' `},
+		{`mixed: "it's" # quote inside`, `mixed: "it's" `},
+	}
+
+	for _, tt := range tests {
+		if got := stripComment(tt.line); got != tt.want {
+			t.Errorf("stripComment(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseConfigValue(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "redis", want: "redis"},
+		{value: "", want: ""},
+		{value: `"a\tb"`, want: "a\tb"},
+		{value: `'a\tb'`, want: `a\tb`},
+		{value: "[a, b, c]", want: "a,b,c"},
+		{value: `["a", 'b', c]`, want: "a,b,c"},
+		{value: "[]", want: ""},
+		{value: "[a, , b]", want: "a,b"},
+		{value: "[a, b", wantErr: true},
+		{value: `"unterminated`, wantErr: true},
+		{value: "'unterminated", wantErr: true},
+		{value: "'", wantErr: true},
+		{value: `["a, b]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseConfigValue(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseConfigValue(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseConfigValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestUnquoteConfigValue(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "plain", want: "plain"},
+		{value: `"quoted"`, want: "quoted"},
+		{value: `"line\nbreak"`, want: "line\nbreak"},
+		{value: "'literal\\n'", want: `literal\n`},
+		{value: "''", want: ""},
+		{value: `"bad`, wantErr: true},
+		{value: "'bad", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := unquoteConfigValue(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unquoteConfigValue(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("unquoteConfigValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+// writeConfigFile writes content to a file with the given name in a temporary
+// directory and clears the variables it sets, restoring them after the test
+func writeConfigFile(t *testing.T, name, content string, keys ...string) string {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(key, "")
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "yaml",
+			file: "config.yaml",
+			content: "---\n" +
+				"# Redis\n" +
+				"redis_host: redis\n" +
+				"REDIS_PORT: \"6380\" # quoted\n" +
+				"exclude_tags: [студия, 'хостел']\n",
+			want: map[string]string{
+				"REDIS_HOST":   "redis",
+				"REDIS_PORT":   "6380",
+				"EXCLUDE_TAGS": "студия,хостел",
+			},
+		},
+		{
+			name:    "yml extension",
+			file:    "config.YML",
+			content: "redis_host: redis\n",
+			want:    map[string]string{"REDIS_HOST": "redis"},
+		},
+		{
+			name: "toml",
+			file: "config.toml",
+			content: "REDIS_HOST = \"redis\"\n" +
+				"\n" +
+				"avito_url = \"https://www.avito.ru/moskva?q=a#b\"\n",
+			want: map[string]string{
+				"REDIS_HOST": "redis",
+				"AVITO_URL":  "https://www.avito.ru/moskva?q=a#b",
+			},
+		},
+		{
+			name:    "unsupported format",
+			file:    "config.json",
+			content: "{}",
+			wantErr: "unsupported config file format",
+		},
+		{
+			name:    "toml separator in yaml",
+			file:    "config.yaml",
+			content: "redis_host = redis\n",
+			wantErr: "line 1: expected KEY: value",
+		},
+		{
+			name:    "nested section",
+			file:    "config.toml",
+			content: "REDIS_HOST = \"redis\"\n[redis]\n",
+			wantErr: "line 2: expected KEY = value",
+		},
+		{
+			name:    "invalid key",
+			file:    "config.yaml",
+			content: "redis-host: redis\n",
+			wantErr: "line 1",
+		},
+		{
+			name:    "unterminated list",
+			file:    "config.yaml",
+			content: "redis_host: redis\nexclude_tags: [a, b\n",
+			wantErr: "line 2: unterminated list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := make([]string, 0, len(tt.want))
+			for key := range tt.want {
+				keys = append(keys, key)
+			}
+			path := writeConfigFile(t, tt.file, tt.content, keys...)
+
+			err := loadConfigFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadConfigFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfigFile() error = %v", err)
+			}
+			for key, want := range tt.want {
+				if got := os.Getenv(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileKeepsEnvironment(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "redis_host: file\nredis_port: 6380\n", "REDIS_HOST", "REDIS_PORT")
+	t.Setenv("REDIS_HOST", "env")
+
+	if err := loadConfigFile(path); err != nil {
+		t.Fatalf("loadConfigFile() error = %v", err)
+	}
+	if got := os.Getenv("REDIS_HOST"); got != "env" {
+		t.Errorf("REDIS_HOST = %q, want the environment value %q", got, "env")
+	}
+	if got := os.Getenv("REDIS_PORT"); got != "6380" {
+		t.Errorf("REDIS_PORT = %q, want the file value %q", got, "6380")
+	}
+}
+
+func TestLoadWithConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr string
+	}{
+		{
+			name:    "file fills unset variables",
+			content: "redis_host: redis\nexclude_tags: [студия, хостел]\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Redis.Host != "redis" {
+					t.Errorf("Redis.Host = %q, want %q", cfg.Redis.Host, "redis")
+				}
+				want := []string{"студия", "хостел"}
+				if !reflect.DeepEqual(cfg.Parser.ExcludeTags, want) {
+					t.Errorf("Parser.ExcludeTags = %q, want %q", cfg.Parser.ExcludeTags, want)
+				}
+			},
+		},
+		{
+			name:    "environment overrides file",
+			content: "redis_host: redis\nredis_port: 6380\n",
+			env:     map[string]string{"REDIS_HOST": "localhost"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Redis.Host != "localhost" {
+					t.Errorf("Redis.Host = %q, want %q", cfg.Redis.Host, "localhost")
+				}
+				if cfg.Redis.Port != "6380" {
+					t.Errorf("Redis.Port = %q, want %q", cfg.Redis.Port, "6380")
+				}
+			},
+		},
+		{
+			name:    "file values are validated",
+			content: "redis_db: 20\n",
+			wantErr: "out of range",
+		},
+		{
+			name:    "invalid file",
+			content: "redis_host redis\n",
+			wantErr: "invalid CONFIG_FILE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, "config.yaml", tt.content,
+				"REDIS_HOST", "REDIS_PORT", "REDIS_DB", "EXCLUDE_TAGS")
+			t.Setenv("CONFIG_FILE", path)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"log"
+	"time"
+
+	"avito-parser/internal/clock"
+)
+
+// ConnectWithRetry calls connect until it succeeds, up to retries more times
+// after the first attempt, doubling the pause from backoff after each failure.
+// It lets the parser wait for a store that starts together with it, e.g. Redis
+// in docker-compose, instead of exiting. The last error is returned.
+func ConnectWithRetry(clk clock.Clock, retries int, backoff time.Duration, connect func() (Store, error)) (Store, error) {
+	attempts := retries + 1
+	for attempt := 1; ; attempt++ {
+		store, err := connect()
+		if err == nil {
+			return store, nil
+		}
+		if attempt >= attempts {
+			return nil, err
+		}
+
+		log.Printf("Storage connection attempt %d of %d failed: %v, retrying in %v", attempt, attempts, err, backoff)
+		<-clk.After(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"avito-parser/internal/clock"
+)
+
+func TestConnectWithRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		retries  int
+		failures int
+		// pauses are the waits expected between the attempts
+		pauses  []time.Duration
+		wantErr bool
+	}{
+		{"first attempt", 3, 0, nil, false},
+		{"up after two failures", 3, 2, []time.Duration{time.Second, 2 * time.Second}, false},
+		{"up at the last retry", 3, 3, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, false},
+		{"never up", 3, 10, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, true},
+		{"no retries", 0, 10, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			clk := clock.NewFake(start)
+			up := newMemoryStore(nil)
+
+			var calls []time.Duration
+			connect := func() (Store, error) {
+				calls = append(calls, clk.Now().Sub(start))
+				if len(calls) <= tt.failures {
+					return nil, fmt.Errorf("dial tcp: connection refused (attempt %d)", len(calls))
+				}
+				return up, nil
+			}
+
+			type result struct {
+				store Store
+				err   error
+			}
+			done := make(chan result, 1)
+			go func() {
+				store, err := ConnectWithRetry(clk, tt.retries, time.Second, connect)
+				done <- result{store, err}
+			}()
+
+			// Advance to just before each pause ends, then past it
+			for _, pause := range tt.pauses {
+				waitForWaiter(t, clk)
+				clk.Advance(pause - time.Millisecond)
+				if clk.Waiters() == 0 {
+					t.Fatalf("retried before the %v pause ended", pause)
+				}
+				clk.Advance(time.Millisecond)
+			}
+
+			var got result
+			select {
+			case got = <-done:
+			case <-time.After(time.Second):
+				t.Fatal("ConnectWithRetry() still waits after the expected pauses")
+			}
+
+			if (got.err != nil) != tt.wantErr {
+				t.Fatalf("ConnectWithRetry() error = %v, wantErr %v", got.err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if want := fmt.Sprintf("(attempt %d)", tt.retries+1); !strings.HasSuffix(got.err.Error(), want) {
+					t.Errorf("ConnectWithRetry() error = %v, want the error of the last attempt", got.err)
+				}
+			} else if got.store != up {
+				t.Errorf("ConnectWithRetry() = %v, want the connected store", got.store)
+			}
+
+			// Each attempt starts after the doubled pause before it
+			want := []time.Duration{0}
+			for _, pause := range tt.pauses {
+				want = append(want, want[len(want)-1]+pause)
+			}
+			if !reflect.DeepEqual(calls, want) {
+				t.Errorf("attempts at %v, want %v", calls, want)
+			}
+		})
+	}
+}
+
+// waitForWaiter blocks until something waits on the fake clock
+func waitForWaiter(t *testing.T, clk *clock.Fake) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clk.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("nothing waits on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+// FileStore keeps each value as an individual <key>.json file in a directory
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file store, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file store directory: %w", err)
+	}
+
+	log.Printf("Using file store in %s", dir)
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file path for a key
+func (f *FileStore) path(key string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(key)
+	return filepath.Join(f.dir, name+".json")
+}
+
+// Set writes the value atomically via a temp file and rename.
+// Expiration is not supported by files and is ignored.
+func (f *FileStore) Set(key, value string, expiration time.Duration) error {
+	path := f.path(key)
+
+	// Skip identical rewrites so unchanged listings keep their file untouched
+	existing, err := os.ReadFile(path)
+	if err == nil && string(existing) == value {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(f.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.WriteString(value)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+	return nil
+}
+
+// Get reads the value of a key
+func (f *FileStore) Get(key string) (string, error) {
+	data, err := os.ReadFile(f.path(key))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Exists checks if a file for the key exists
+func (f *FileStore) Exists(key string) (bool, error) {
+	_, err := os.Stat(f.path(key))
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// Delete removes the file of a key
+func (f *FileStore) Delete(key string) error {
+	err := os.Remove(f.path(key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// All reads every listing file in the directory
+func (f *FileStore) All() ([]string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file store directory: %w", err)
+	}
+
+	var values []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, ListingKeyPrefix) || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(f.dir, name))
+		if err != nil {
+			log.Printf("Failed to read %s: %v", name, err)
+			continue
+		}
+		values = append(values, string(data))
+	}
+
+	return values, nil
+}
+
+// Count counts the listing files without reading them
+func (f *FileStore) Count() (int64, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list file store directory: %w", err)
+	}
+
+	var count int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, ListingKeyPrefix) && strings.HasSuffix(name, ".json") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ScanListings reads count listing files starting at the cursor, the position
+// of the first file in name order
+func (f *FileStore) ScanListings(cursor uint64, count int64) ([]*models.Listing, uint64, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list file store directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, ListingKeyPrefix) && strings.HasSuffix(name, ".json") {
+			names = append(names, name)
+		}
+	}
+
+	if count <= 0 {
+		count = 100
+	}
+	start := min(cursor, uint64(len(names)))
+	end := min(start+uint64(count), uint64(len(names)))
+
+	// The cursor is back at 0 after the last page, like Redis SCAN
+	next := end
+	if end == uint64(len(names)) {
+		next = 0
+	}
+
+	var listings []*models.Listing
+	for _, name := range names[start:end] {
+		data, err := os.ReadFile(filepath.Join(f.dir, name))
+		if err != nil {
+			log.Printf("Failed to read %s: %v", name, err)
+			continue
+		}
+
+		listing, err := models.FromJSON(data)
+		if err != nil {
+			log.Printf("Skipping unreadable listing %s: %v", name, err)
+			continue
+		}
+		listings = append(listings, listing)
+	}
+	return listings, next, nil
+}
+
+// DeleteWhere deletes the listing files pred matches with the keys kept about them
+func (f *FileStore) DeleteWhere(pred func(*models.Listing) bool) (int, error) {
+	return deleteWhere(f, pred, func(listing *models.Listing) error {
+		return deleteListingKeys(f, listing)
+	})
+}
+
+// Close does nothing, files need no cleanup
+func (f *FileStore) Close() error {
+	return nil
+}
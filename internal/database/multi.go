@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+// MultiStore writes every value to several stores at once. Reads are served by
+// the primary store, so a listing is new only when the primary doesn't have it.
+// Write errors of the other stores are logged and never fail the operation.
+type MultiStore struct {
+	primary Store
+	others  []Store
+}
+
+// NewMultiStore creates a store fanning writes out to the primary and the others
+func NewMultiStore(primary Store, others ...Store) *MultiStore {
+	return &MultiStore{primary: primary, others: others}
+}
+
+// WithContext binds the calls of every store to ctx
+func (m *MultiStore) WithContext(ctx context.Context) Store {
+	others := make([]Store, 0, len(m.others))
+	for _, store := range m.others {
+		others = append(others, WithContext(ctx, store))
+	}
+	return NewMultiStore(WithContext(ctx, m.primary), others...)
+}
+
+// Set writes the value to all stores, returning the error of the primary only
+func (m *MultiStore) Set(key, value string, expiration time.Duration) error {
+	err := m.primary.Set(key, value, expiration)
+	if err != nil {
+		return err
+	}
+
+	for i, store := range m.others {
+		if err := store.Set(key, value, expiration); err != nil {
+			log.Printf("Failed to write %s to storage backend %d: %v", key, i+2, err)
+		}
+	}
+	return nil
+}
+
+// Get reads the value from the primary store
+func (m *MultiStore) Get(key string) (string, error) {
+	return m.primary.Get(key)
+}
+
+// Exists checks the key in the primary store
+func (m *MultiStore) Exists(key string) (bool, error) {
+	return m.primary.Exists(key)
+}
+
+// Delete removes the key from all stores, returning the error of the primary only
+func (m *MultiStore) Delete(key string) error {
+	err := m.primary.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	for i, store := range m.others {
+		if err := store.Delete(key); err != nil {
+			log.Printf("Failed to delete %s from storage backend %d: %v", key, i+2, err)
+		}
+	}
+	return nil
+}
+
+// All returns the listings of the primary store
+func (m *MultiStore) All() ([]string, error) {
+	return m.primary.All()
+}
+
+// ScanListings pages through the listings of the primary store, which must
+// support scanning
+func (m *MultiStore) ScanListings(cursor uint64, count int64) ([]*models.Listing, uint64, error) {
+	scanner, ok := m.primary.(Scanner)
+	if !ok {
+		return nil, 0, errors.New("primary storage backend can't scan listings")
+	}
+	return scanner.ScanListings(cursor, count)
+}
+
+// Count counts the listings of the primary store
+func (m *MultiStore) Count() (int64, error) {
+	return Count(m.primary)
+}
+
+// sortedIndexer returns the primary store as a sorted indexer
+func (m *MultiStore) sortedIndexer() (SortedIndexer, error) {
+	indexer, ok := m.primary.(SortedIndexer)
+	if !ok {
+		return nil, errors.New("primary storage backend can't keep sorted indexes")
+	}
+	return indexer, nil
+}
+
+// ZAdd adds the members to the sorted index of the primary store
+func (m *MultiStore) ZAdd(index string, scores map[string]float64) error {
+	indexer, err := m.sortedIndexer()
+	if err != nil {
+		return err
+	}
+	return indexer.ZAdd(index, scores)
+}
+
+// ZRange returns the members of the sorted index of the primary store
+func (m *MultiStore) ZRange(index string, start, stop int64) ([]string, error) {
+	indexer, err := m.sortedIndexer()
+	if err != nil {
+		return nil, err
+	}
+	return indexer.ZRange(index, start, stop)
+}
+
+// ZRem removes the members from the sorted index of the primary store
+func (m *MultiStore) ZRem(index string, members ...string) error {
+	indexer, err := m.sortedIndexer()
+	if err != nil {
+		return err
+	}
+	return indexer.ZRem(index, members...)
+}
+
+// ZCard counts the members of the sorted index of the primary store
+func (m *MultiStore) ZCard(index string) (int64, error) {
+	indexer, err := m.sortedIndexer()
+	if err != nil {
+		return 0, err
+	}
+	return indexer.ZCard(index)
+}
+
+// Expire resets the time to live of the key in every store with expiring keys
+func (m *MultiStore) Expire(key string, ttl time.Duration) error {
+	if expirer, ok := m.primary.(Expirer); ok {
+		if err := expirer.Expire(key, ttl); err != nil {
+			return err
+		}
+	}
+
+	for i, store := range m.others {
+		expirer, ok := store.(Expirer)
+		if !ok {
+			continue
+		}
+		if err := expirer.Expire(key, ttl); err != nil {
+			log.Printf("Failed to refresh expiry of %s in storage backend %d: %v", key, i+2, err)
+		}
+	}
+	return nil
+}
+
+// GetMany reads the values from the primary store
+func (m *MultiStore) GetMany(keys []string) (map[string]string, error) {
+	return getMany(m.primary, keys)
+}
+
+// SetMany writes the values to all stores, reporting the failures of the primary only
+func (m *MultiStore) SetMany(values map[string]string, expiration time.Duration) (map[string]error, error) {
+	failed, err := setMany(m.primary, values, expiration)
+	if err != nil {
+		return failed, err
+	}
+
+	for i, store := range m.others {
+		otherFailed, err := setMany(store, values, expiration)
+		if err != nil {
+			log.Printf("Failed to write %d values to storage backend %d: %v", len(values), i+2, err)
+			continue
+		}
+		for key, err := range otherFailed {
+			log.Printf("Failed to write %s to storage backend %d: %v", key, i+2, err)
+		}
+	}
+	return failed, nil
+}
+
+// DeleteWhere deletes the matching listings from all stores, returning the
+// count and the error of the primary only
+func (m *MultiStore) DeleteWhere(pred func(*models.Listing) bool) (int, error) {
+	deleted, err := m.primary.DeleteWhere(pred)
+	if err != nil {
+		return deleted, err
+	}
+
+	for i, store := range m.others {
+		if _, err := store.DeleteWhere(pred); err != nil {
+			log.Printf("Failed to delete listings from storage backend %d: %v", i+2, err)
+		}
+	}
+	return deleted, nil
+}
+
+// Close closes all stores
+func (m *MultiStore) Close() error {
+	errs := []error{m.primary.Close()}
+	for _, store := range m.others {
+		errs = append(errs, store.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// getMany reads many keys in one round trip when the store supports it
+func getMany(store Store, keys []string) (map[string]string, error) {
+	if batch, ok := store.(BatchStore); ok {
+		return batch.GetMany(keys)
+	}
+
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		exists, err := store.Exists(key)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+
+		value, err := store.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// setMany writes many values in one round trip when the store supports it
+func setMany(store Store, values map[string]string, expiration time.Duration) (map[string]error, error) {
+	if batch, ok := store.(BatchStore); ok {
+		return batch.SetMany(values, expiration)
+	}
+
+	failed := make(map[string]error)
+	for key, value := range values {
+		if err := store.Set(key, value, expiration); err != nil {
+			failed[key] = err
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+// memoryStore is an in-memory Store for tests. Writes fail with failWrites when set.
+type memoryStore struct {
+	values     map[string]string
+	failWrites error
+	closed     bool
+}
+
+func newMemoryStore(values map[string]string) *memoryStore {
+	store := &memoryStore{values: make(map[string]string)}
+	for key, value := range values {
+		store.values[key] = value
+	}
+	return store
+}
+
+func (m *memoryStore) Set(key, value string, expiration time.Duration) error {
+	if m.failWrites != nil {
+		return m.failWrites
+	}
+	m.values[key] = value
+	return nil
+}
+
+func (m *memoryStore) Get(key string) (string, error) {
+	value, ok := m.values[key]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func (m *memoryStore) Exists(key string) (bool, error) {
+	_, ok := m.values[key]
+	return ok, nil
+}
+
+func (m *memoryStore) Delete(key string) error {
+	if m.failWrites != nil {
+		return m.failWrites
+	}
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memoryStore) All() ([]string, error) {
+	var values []string
+	for key, value := range m.values {
+		if strings.HasPrefix(key, ListingKeyPrefix) {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+func (m *memoryStore) DeleteWhere(pred func(*models.Listing) bool) (int, error) {
+	return deleteWhere(m, pred, func(listing *models.Listing) error {
+		return deleteListingKeys(m, listing)
+	})
+}
+
+func (m *memoryStore) Close() error {
+	m.closed = true
+	return nil
+}
+
+func keys(store *memoryStore) []string {
+	var keys []string
+	for key := range store.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestMultiStoreFansOutWrites(t *testing.T) {
+	primary := newMemoryStore(nil)
+	archive := newMemoryStore(nil)
+	broken := newMemoryStore(nil)
+	broken.failWrites = errors.New("disk full")
+	multi := NewMultiStore(primary, broken, archive)
+
+	if err := multi.Set("listing_1", "one", time.Hour); err != nil {
+		t.Fatalf("Set() error = %v, want errors of other stores ignored", err)
+	}
+	failed, err := multi.SetMany(map[string]string{"listing_2": "two", "listing_3": "three"}, time.Hour)
+	if err != nil || len(failed) != 0 {
+		t.Fatalf("SetMany() = %v, %v, want no failures", failed, err)
+	}
+
+	want := []string{"listing_1", "listing_2", "listing_3"}
+	for name, store := range map[string]*memoryStore{"primary": primary, "archive": archive} {
+		if got := keys(store); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s keys = %v, want %v", name, got, want)
+		}
+	}
+
+	if err := multi.Delete("listing_2"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	want = []string{"listing_1", "listing_3"}
+	for name, store := range map[string]*memoryStore{"primary": primary, "archive": archive} {
+		if got := keys(store); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s keys after Delete = %v, want %v", name, got, want)
+		}
+	}
+
+	if err := multi.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !primary.closed || !archive.closed || !broken.closed {
+		t.Error("Close() left a store open")
+	}
+}
+
+func TestMultiStorePrimaryErrors(t *testing.T) {
+	primary := newMemoryStore(nil)
+	primary.failWrites = errors.New("redis down")
+	archive := newMemoryStore(nil)
+	multi := NewMultiStore(primary, archive)
+
+	if err := multi.Set("listing_1", "one", time.Hour); err == nil {
+		t.Error("Set() error = nil, want the primary error")
+	}
+	if len(archive.values) != 0 {
+		t.Errorf("archive keys = %v, want nothing written after the primary failed", keys(archive))
+	}
+
+	failed, err := multi.SetMany(map[string]string{"listing_2": "two"}, time.Hour)
+	if err != nil {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+	if _, ok := failed["listing_2"]; !ok || len(failed) != 1 {
+		t.Errorf("SetMany() failed = %v, want the primary failure of listing_2", failed)
+	}
+}
+
+func TestMultiStoreNewOnlyInPrimary(t *testing.T) {
+	primary := newMemoryStore(map[string]string{"listing_both": "p", "listing_primary": "p"})
+	archive := newMemoryStore(map[string]string{"listing_both": "a", "listing_archive": "a"})
+	multi := NewMultiStore(primary, archive)
+
+	tests := []struct {
+		key    string
+		exists bool
+	}{
+		{"listing_both", true},
+		{"listing_primary", true},
+		{"listing_archive", false},
+		{"listing_none", false},
+	}
+
+	for _, tt := range tests {
+		exists, err := multi.Exists(tt.key)
+		if err != nil {
+			t.Fatalf("Exists(%q) error = %v", tt.key, err)
+		}
+		if exists != tt.exists {
+			t.Errorf("Exists(%q) = %v, want %v", tt.key, exists, tt.exists)
+		}
+	}
+
+	values, err := multi.GetMany([]string{"listing_both", "listing_primary", "listing_archive", "listing_none"})
+	if err != nil {
+		t.Fatalf("GetMany() error = %v", err)
+	}
+	want := map[string]string{"listing_both": "p", "listing_primary": "p"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("GetMany() = %v, want the primary values %v", values, want)
+	}
+}
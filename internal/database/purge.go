@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"avito-parser/internal/models"
+)
+
+// purgeScanSize is how many listings DeleteWhere reads per scan step
+const purgeScanSize = 500
+
+// relatedKeys returns the keys kept about a listing besides its record
+func relatedKeys(id string) []string {
+	return []string{FirstSeenKey(id), DeadLetterKey(id)}
+}
+
+// relatedIndexes returns the index sets that may hold the id of the listing
+func relatedIndexes(listing *models.Listing) []string {
+	indexes := []string{DeadLetterIndex}
+	if listing.Signature != "" {
+		indexes = append(indexes, FingerprintIndex(listing.Signature))
+	}
+	if listing.ImageHash != "" {
+		indexes = append(indexes, ImageIndex(listing.ImageHash))
+	}
+	return indexes
+}
+
+// deleteWhere deletes the stored listings pred matches with deleteListing and
+// returns how many were deleted. Matches are collected over the whole store
+// before anything is deleted, so deleting can't make the scan skip listings.
+func deleteWhere(store Store, pred func(*models.Listing) bool, deleteListing func(*models.Listing) error) (int, error) {
+	listings, err := matchingListings(store, pred)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, listing := range listings {
+		if err := deleteListing(listing); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", listing.ID, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// deleteListingKeys deletes the record of a listing and the keys kept about
+// it, for stores without indexes
+func deleteListingKeys(store Store, listing *models.Listing) error {
+	if err := store.Delete(listing.ID); err != nil {
+		return err
+	}
+
+	for _, key := range relatedKeys(listing.ID) {
+		if err := store.Delete(key); err != nil {
+			log.Printf("Failed to delete %s of listing %s: %v", key, listing.ID, err)
+		}
+	}
+	return nil
+}
+
+// matchingListings returns the stored listings pred matches, paging through
+// the store when it can scan
+func matchingListings(store Store, pred func(*models.Listing) bool) ([]*models.Listing, error) {
+	matched := make(map[string]bool)
+	var listings []*models.Listing
+	match := func(listing *models.Listing) {
+		if listing.ID != "" && !matched[listing.ID] && pred(listing) {
+			matched[listing.ID] = true
+			listings = append(listings, listing)
+		}
+	}
+
+	scanner, ok := store.(Scanner)
+	if !ok {
+		values, err := store.All()
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			listing, err := models.FromJSON([]byte(value))
+			if err != nil {
+				continue
+			}
+			match(listing)
+		}
+		return listings, nil
+	}
+
+	var cursor uint64
+	for {
+		page, next, err := scanner.ScanListings(cursor, purgeScanSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, listing := range page {
+			match(listing)
+		}
+		if next == 0 {
+			return listings, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"avito-parser/internal/models"
+)
+
+// storeListings saves listings with the given ruble prices as listing_<n>,
+// each with a first-seen time and a dead letter
+func storeListings(t *testing.T, store Store, prices ...int64) {
+	t.Helper()
+	for i, price := range prices {
+		listing := &models.Listing{ID: fmt.Sprintf("listing_%d", i+1), PriceRUB: price}
+		data, err := listing.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range map[string]string{
+			listing.ID:                string(data),
+			FirstSeenKey(listing.ID):  "2024-01-01T00:00:00Z",
+			DeadLetterKey(listing.ID): "failed",
+		} {
+			if err := store.Set(key, value, 0); err != nil {
+				t.Fatalf("Set(%q) error = %v", key, err)
+			}
+		}
+	}
+}
+
+func priceAbove(limit int64) func(*models.Listing) bool {
+	return func(listing *models.Listing) bool {
+		return listing.PriceRUB > limit
+	}
+}
+
+func TestDeleteWhere(t *testing.T) {
+	tests := []struct {
+		name    string
+		prices  []int64
+		pred    func(*models.Listing) bool
+		deleted int
+		kept    []string
+	}{
+		{
+			name:    "some match",
+			prices:  []int64{10000, 50000, 20000, 90000},
+			pred:    priceAbove(30000),
+			deleted: 2,
+			kept:    []string{"listing_1", "listing_3"},
+		},
+		{
+			name:    "none match",
+			prices:  []int64{10000, 20000},
+			pred:    priceAbove(30000),
+			deleted: 0,
+			kept:    []string{"listing_1", "listing_2"},
+		},
+		{
+			name:    "all match",
+			prices:  []int64{40000, 50000},
+			pred:    priceAbove(30000),
+			deleted: 2,
+		},
+		{
+			name:    "empty store",
+			pred:    priceAbove(0),
+			deleted: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore(nil)
+			storeListings(t, store, tt.prices...)
+
+			deleted, err := store.DeleteWhere(tt.pred)
+			if err != nil {
+				t.Fatalf("DeleteWhere() error = %v", err)
+			}
+			if deleted != tt.deleted {
+				t.Errorf("DeleteWhere() = %d, want %d", deleted, tt.deleted)
+			}
+
+			var want []string
+			for _, id := range tt.kept {
+				want = append(want, DeadLetterKey(id), FirstSeenKey(id), id)
+			}
+			sort.Strings(want)
+			if got := keys(store); !reflect.DeepEqual(got, want) {
+				t.Errorf("keys left = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDeleteWhereScansBeforeDeleting(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// More listings than one scan step, every other one matching. Deleting
+	// while paging by position would shift later listings past the cursor.
+	prices := make([]int64, 3*purgeScanSize/2)
+	for i := range prices {
+		prices[i] = int64(i%2) * 100000
+	}
+	storeListings(t, store, prices...)
+
+	deleted, err := store.DeleteWhere(priceAbove(0))
+	if err != nil {
+		t.Fatalf("DeleteWhere() error = %v", err)
+	}
+	if deleted != len(prices)/2 {
+		t.Errorf("DeleteWhere() = %d, want %d", deleted, len(prices)/2)
+	}
+	if count, _ := store.Count(); count != int64(len(prices)/2) {
+		t.Errorf("Count() after DeleteWhere = %d, want %d", count, len(prices)/2)
+	}
+	if exists, _ := store.Exists(FirstSeenKey("listing_2")); exists {
+		t.Error("first-seen time of a deleted listing was kept")
+	}
+}
+
+func TestReadOnlyStoreDeleteWhere(t *testing.T) {
+	wrapped := newMemoryStore(nil)
+	storeListings(t, wrapped, 10000, 50000, 90000)
+	before := keys(wrapped)
+
+	deleted, err := NewReadOnlyStore(wrapped).DeleteWhere(priceAbove(30000))
+	if err != nil {
+		t.Fatalf("DeleteWhere() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteWhere() = %d, want the 2 listings it would delete", deleted)
+	}
+	if got := keys(wrapped); !reflect.DeepEqual(got, before) {
+		t.Errorf("keys = %v, want nothing deleted", got)
+	}
+}
+
+func TestMultiStoreDeleteWhere(t *testing.T) {
+	primary := newMemoryStore(nil)
+	archive := newMemoryStore(nil)
+	storeListings(t, primary, 10000, 50000)
+	storeListings(t, archive, 10000, 50000, 90000)
+
+	deleted, err := NewMultiStore(primary, archive).DeleteWhere(priceAbove(30000))
+	if err != nil {
+		t.Fatalf("DeleteWhere() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("DeleteWhere() = %d, want the count of the primary", deleted)
+	}
+	for name, store := range map[string]*memoryStore{"primary": primary, "archive": archive} {
+		if values, _ := store.All(); len(values) != 1 {
+			t.Errorf("%s holds %d listings, want 1", name, len(values))
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+// ReadOnlyStore serves reads from the wrapped store and drops every write, so
+// a dry run still tells new listings from updated ones without changing the
+// stored data. Dropped writes report success and are counted.
+type ReadOnlyStore struct {
+	store   Store
+	skipped *atomic.Int64
+}
+
+// NewReadOnlyStore wraps the store so that nothing is written to it
+func NewReadOnlyStore(store Store) *ReadOnlyStore {
+	return &ReadOnlyStore{store: store, skipped: new(atomic.Int64)}
+}
+
+// WithContext binds the reads of the wrapped store to ctx
+func (s *ReadOnlyStore) WithContext(ctx context.Context) Store {
+	return &ReadOnlyStore{store: WithContext(ctx, s.store), skipped: s.skipped}
+}
+
+// Set drops the write
+func (s *ReadOnlyStore) Set(key, value string, expiration time.Duration) error {
+	s.skipped.Add(1)
+	return nil
+}
+
+// Get reads the value from the wrapped store
+func (s *ReadOnlyStore) Get(key string) (string, error) {
+	return s.store.Get(key)
+}
+
+// Exists checks the key in the wrapped store
+func (s *ReadOnlyStore) Exists(key string) (bool, error) {
+	return s.store.Exists(key)
+}
+
+// Delete drops the deletion
+func (s *ReadOnlyStore) Delete(key string) error {
+	s.skipped.Add(1)
+	return nil
+}
+
+// All returns the listings of the wrapped store
+func (s *ReadOnlyStore) All() ([]string, error) {
+	return s.store.All()
+}
+
+// Expire drops the expiry reset
+func (s *ReadOnlyStore) Expire(key string, ttl time.Duration) error {
+	s.skipped.Add(1)
+	return nil
+}
+
+// GetMany reads the values from the wrapped store
+func (s *ReadOnlyStore) GetMany(keys []string) (map[string]string, error) {
+	return getMany(s.store, keys)
+}
+
+// SetMany drops the writes
+func (s *ReadOnlyStore) SetMany(values map[string]string, expiration time.Duration) (map[string]error, error) {
+	s.skipped.Add(int64(len(values)))
+	return map[string]error{}, nil
+}
+
+// AddToIndex drops the index update
+func (s *ReadOnlyStore) AddToIndex(index, member string, expiration time.Duration) error {
+	s.skipped.Add(1)
+	return nil
+}
+
+// IndexMembers returns the members of the index in the wrapped store, none
+// when it keeps no indexes
+func (s *ReadOnlyStore) IndexMembers(index string) ([]string, error) {
+	indexer, ok := s.store.(Indexer)
+	if !ok {
+		return nil, nil
+	}
+	return indexer.IndexMembers(index)
+}
+
+// ZAdd drops the index update
+func (s *ReadOnlyStore) ZAdd(index string, scores map[string]float64) error {
+	s.skipped.Add(int64(len(scores)))
+	return nil
+}
+
+// ZRange returns the members of the sorted index in the wrapped store, none
+// when it keeps no sorted indexes
+func (s *ReadOnlyStore) ZRange(index string, start, stop int64) ([]string, error) {
+	indexer, ok := s.store.(SortedIndexer)
+	if !ok {
+		return nil, nil
+	}
+	return indexer.ZRange(index, start, stop)
+}
+
+// ZRem drops the index update
+func (s *ReadOnlyStore) ZRem(index string, members ...string) error {
+	s.skipped.Add(1)
+	return nil
+}
+
+// ZCard counts the members of the sorted index in the wrapped store, 0 when
+// it keeps no sorted indexes
+func (s *ReadOnlyStore) ZCard(index string) (int64, error) {
+	indexer, ok := s.store.(SortedIndexer)
+	if !ok {
+		return 0, nil
+	}
+	return indexer.ZCard(index)
+}
+
+// Count counts the listings of the wrapped store
+func (s *ReadOnlyStore) Count() (int64, error) {
+	return Count(s.store)
+}
+
+// ScanListings pages through the listings of the wrapped store, which must support scanning
+func (s *ReadOnlyStore) ScanListings(cursor uint64, count int64) ([]*models.Listing, uint64, error) {
+	scanner, ok := s.store.(Scanner)
+	if !ok {
+		return nil, 0, errors.New("storage backend can't scan listings")
+	}
+	return scanner.ScanListings(cursor, count)
+}
+
+// DeleteWhere drops the deletion, returning how many listings it would delete
+func (s *ReadOnlyStore) DeleteWhere(pred func(*models.Listing) bool) (int, error) {
+	listings, err := matchingListings(s.store, pred)
+	if err != nil {
+		return 0, err
+	}
+	s.skipped.Add(int64(len(listings)))
+	return len(listings), nil
+}
+
+// Close reports the dropped writes and closes the wrapped store
+func (s *ReadOnlyStore) Close() error {
+	log.Printf("Dry run: skipped %d storage writes", s.skipped.Load())
+	return s.store.Close()
+}
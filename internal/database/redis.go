@@ -1,62 +1,603 @@
 package database
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
+	"avito-parser/internal/clock"
+	"avito-parser/internal/models"
+
 	"github.com/go-redis/redis/v8"
 )
 
 type RedisClient struct {
-	client *redis.Client
-	ctx    context.Context
+	client   *redis.Client
+	ctx      context.Context
+	timeout  time.Duration
+	compress bool
+	verbose  bool
+	// clock dates the expiry index, the system clock outside of tests
+	clock clock.Clock
+}
+
+// DefaultOpTimeout bounds a single Redis call of clients made by NewRedisClient
+const DefaultOpTimeout = 5 * time.Second
+
+// Transient errors are retried with exponential backoff starting at retryBackoff
+const (
+	retryAttempts = 3
+	retryBackoff  = 200 * time.Millisecond
+)
+
+// expiryIndex orders the stored listing keys by the Unix time they expire at.
+// It is kept on every save, delete and expiry reset, and expired members are
+// pruned before counting, so Count stays exact without reading every key.
+const expiryIndex = "index:expires"
+
+// expiryScore is the expiryIndex score of a key saved now with the expiration
+func (r *RedisClient) expiryScore(expiration time.Duration) float64 {
+	if expiration <= 0 {
+		return math.Inf(1)
+	}
+	return float64(r.clock.Now().Add(expiration).Unix())
+}
+
+// gzipMagic is the header every gzip stream starts with. JSON values never
+// start with it, so reads can tell compressed values from legacy plain ones.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// NewRedisClient creates a new Redis client whose calls time out after DefaultOpTimeout.
+// When compress is set, values are gzipped before being stored.
+func NewRedisClient(host, port, password string, db int, compress, verbose bool) (*RedisClient, error) {
+	return NewRedisClientWithTimeout(host, port, password, db, compress, verbose, DefaultOpTimeout)
 }
 
-// NewRedisClient creates a new Redis client
-func NewRedisClient(host, port, password string, db int) (*RedisClient, error) {
+// NewRedisClientWithTimeout creates a new Redis client whose calls are cancelled
+// after opTimeout, 0 meaning no limit. Calls of a client bound with WithContext
+// are cancelled with that context as well.
+func NewRedisClientWithTimeout(host, port, password string, db int, compress, verbose bool, opTimeout time.Duration) (*RedisClient, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", host, port),
 		Password: password,
 		DB:       db,
 	})
 
-	ctx := context.Background()
+	r := &RedisClient{
+		client:   rdb,
+		ctx:      context.Background(),
+		timeout:  opTimeout,
+		compress: compress,
+		verbose:  verbose,
+		clock:    clock.Real{},
+	}
 
 	// Test connection
-	_, err := rdb.Ping(ctx).Result()
+	ctx, cancel := r.opContext()
+	defer cancel()
+
+	err := rdb.Ping(ctx).Err()
 	if err != nil {
+		rdb.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	log.Println("Successfully connected to Redis")
+	log.Printf("Successfully connected to Redis (DB %d)", db)
+
+	if compress {
+		log.Println("Redis value compression enabled")
+	}
+
+	return r, nil
+}
 
-	return &RedisClient{
-		client: rdb,
-		ctx:    ctx,
-	}, nil
+// WithContext returns a client sharing the connection whose calls are
+// cancelled together with ctx
+func (r *RedisClient) WithContext(ctx context.Context) Store {
+	bound := *r
+	bound.ctx = ctx
+	return &bound
 }
 
 // Set stores a key-value pair with optional expiration
 func (r *RedisClient) Set(key, value string, expiration time.Duration) error {
-	return r.client.Set(r.ctx, key, value, expiration).Err()
+	if r.compress {
+		compressed, err := compressValue(value)
+		if err != nil {
+			return fmt.Errorf("failed to compress value: %w", err)
+		}
+		if r.verbose {
+			log.Printf("Compressed %s: %d -> %d bytes (%.0f%%)", key, len(value), len(compressed), 100*float64(len(compressed))/float64(len(value)))
+		}
+		value = compressed
+	}
+
+	return r.withRetry(func(ctx context.Context) error {
+		if !strings.HasPrefix(key, ListingKeyPrefix) {
+			return r.client.Set(ctx, key, value, expiration).Err()
+		}
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, value, expiration)
+			pipe.ZAdd(ctx, expiryIndex, &redis.Z{Score: r.expiryScore(expiration), Member: key})
+			return nil
+		})
+		return err
+	})
 }
 
-// Get retrieves a value by key
+// Get retrieves a value by key, transparently decompressing gzipped values
 func (r *RedisClient) Get(key string) (string, error) {
-	return r.client.Get(r.ctx, key).Result()
+	var value string
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		value, err = r.client.Get(ctx, key).Result()
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !bytes.HasPrefix([]byte(value), gzipMagic) {
+		return value, nil
+	}
+
+	return decompressValue(value)
 }
 
 // Exists checks if a key exists
 func (r *RedisClient) Exists(key string) (bool, error) {
-	result := r.client.Exists(r.ctx, key)
-	return result.Val() > 0, result.Err()
+	var count int64
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		count, err = r.client.Exists(ctx, key).Result()
+		return err
+	})
+	return count > 0, err
 }
 
-// Delete removes a key
+// Delete removes a key. A listing is dropped from StoredIndex and the expiry
+// index in the same transaction so the count stays right.
 func (r *RedisClient) Delete(key string) error {
-	return r.client.Del(r.ctx, key).Err()
+	return r.withRetry(func(ctx context.Context) error {
+		if !strings.HasPrefix(key, ListingKeyPrefix) {
+			return r.client.Del(ctx, key).Err()
+		}
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			pipe.ZRem(ctx, StoredIndex, key)
+			pipe.ZRem(ctx, expiryIndex, key)
+			return nil
+		})
+		return err
+	})
+}
+
+// Expire resets the time to live of a key, doing nothing if the key is gone.
+// A listing's score in the expiry index is moved along, if it is indexed.
+func (r *RedisClient) Expire(key string, ttl time.Duration) error {
+	return r.withRetry(func(ctx context.Context) error {
+		if !strings.HasPrefix(key, ListingKeyPrefix) {
+			return r.client.Expire(ctx, key, ttl).Err()
+		}
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Expire(ctx, key, ttl)
+			pipe.ZAddXX(ctx, expiryIndex, &redis.Z{Score: r.expiryScore(ttl), Member: key})
+			return nil
+		})
+		return err
+	})
+}
+
+// GetMany returns the values of the keys that exist, fetched with a single MGET
+func (r *RedisClient) GetMany(keys []string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	var results []interface{}
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		results, err = r.client.MGet(ctx, keys...).Result()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range results {
+		value, ok := result.(string)
+		if !ok {
+			continue // Missing key
+		}
+
+		if bytes.HasPrefix([]byte(value), gzipMagic) {
+			value, err = decompressValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", keys[i], err)
+			}
+		}
+		values[keys[i]] = value
+	}
+
+	return values, nil
+}
+
+// SetMany writes all values in one pipeline, returning the keys that failed with their errors
+func (r *RedisClient) SetMany(values map[string]string, expiration time.Duration) (map[string]error, error) {
+	failed := make(map[string]error)
+	if len(values) == 0 {
+		return failed, nil
+	}
+
+	var cmds map[string]*redis.StatusCmd
+	err := r.withRetry(func(ctx context.Context) error {
+		pipe := r.client.Pipeline()
+		cmds = make(map[string]*redis.StatusCmd, len(values))
+
+		for key, value := range values {
+			if r.compress {
+				compressed, err := compressValue(value)
+				if err != nil {
+					failed[key] = fmt.Errorf("failed to compress value: %w", err)
+					continue
+				}
+				value = compressed
+			}
+			cmds[key] = pipe.Set(ctx, key, value, expiration)
+			if strings.HasPrefix(key, ListingKeyPrefix) {
+				pipe.ZAdd(ctx, expiryIndex, &redis.Z{Score: r.expiryScore(expiration), Member: key})
+			}
+		}
+
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+
+	for key, cmd := range cmds {
+		if cmdErr := cmd.Err(); cmdErr != nil {
+			failed[key] = cmdErr
+		}
+	}
+
+	return failed, err
+}
+
+// DeleteWhere deletes the listings pred matches, see deleteListing
+func (r *RedisClient) DeleteWhere(pred func(*models.Listing) bool) (int, error) {
+	return deleteWhere(r, pred, r.deleteListing)
+}
+
+// deleteListing deletes a listing in one transaction with its first-seen time
+// and dead letter, dropping its id from StoredIndex, the expiry index, the
+// dead letter set and its fingerprint and photo sets
+func (r *RedisClient) deleteListing(listing *models.Listing) error {
+	return r.withRetry(func(ctx context.Context) error {
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, append([]string{listing.ID}, relatedKeys(listing.ID)...)...)
+			pipe.ZRem(ctx, StoredIndex, listing.ID)
+			pipe.ZRem(ctx, expiryIndex, listing.ID)
+			for _, index := range relatedIndexes(listing) {
+				pipe.SRem(ctx, index, listing.ID)
+			}
+			return nil
+		})
+		return err
+	})
+}
+
+// AddToIndex adds the member to the index set, refreshing its expiration
+func (r *RedisClient) AddToIndex(index, member string, expiration time.Duration) error {
+	return r.withRetry(func(ctx context.Context) error {
+		pipe := r.client.TxPipeline()
+		pipe.SAdd(ctx, index, member)
+		pipe.Expire(ctx, index, expiration)
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+}
+
+// IndexMembers returns all members of the index set
+func (r *RedisClient) IndexMembers(index string) ([]string, error) {
+	var members []string
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		members, err = r.client.SMembers(ctx, index).Result()
+		return err
+	})
+	return members, err
+}
+
+// ZAdd adds the members to the sorted index or updates their scores in one call
+func (r *RedisClient) ZAdd(index string, scores map[string]float64) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	members := make([]*redis.Z, 0, len(scores))
+	for member, score := range scores {
+		members = append(members, &redis.Z{Score: score, Member: member})
+	}
+	return r.withRetry(func(ctx context.Context) error {
+		return r.client.ZAdd(ctx, index, members...).Err()
+	})
+}
+
+// ZRange returns the members from start to stop by ascending score
+func (r *RedisClient) ZRange(index string, start, stop int64) ([]string, error) {
+	var members []string
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		members, err = r.client.ZRange(ctx, index, start, stop).Result()
+		return err
+	})
+	return members, err
+}
+
+// ZRem removes the members from the sorted index
+func (r *RedisClient) ZRem(index string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	values := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		values = append(values, member)
+	}
+	return r.withRetry(func(ctx context.Context) error {
+		return r.client.ZRem(ctx, index, values...).Err()
+	})
+}
+
+// ZCard returns how many members the sorted index has
+func (r *RedisClient) ZCard(index string) (int64, error) {
+	var count int64
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		count, err = r.client.ZCard(ctx, index).Result()
+		return err
+	})
+	return count, err
+}
+
+// Count returns how many listings are stored. Once the expiry index exists it
+// drops the listings whose time to live ran out and counts the rest; before
+// that, e.g. on data saved by older versions, the listing keys are counted
+// with SCAN without fetching their values.
+func (r *RedisClient) Count() (int64, error) {
+	var indexed int64
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		indexed, err = r.client.Exists(ctx, expiryIndex).Result()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if indexed == 0 {
+		return r.countKeys()
+	}
+
+	err = r.pruneExpired()
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune expired listings: %w", err)
+	}
+	return r.ZCard(expiryIndex)
+}
+
+// pruneExpired removes listings whose time to live ran out from the expiry
+// index and StoredIndex
+func (r *RedisClient) pruneExpired() error {
+	now := "(" + strconv.FormatInt(r.clock.Now().Unix(), 10)
+
+	var expired []string
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		expired, err = r.client.ZRangeByScore(ctx, expiryIndex, &redis.ZRangeBy{Min: "-inf", Max: now}).Result()
+		return err
+	})
+	if err != nil || len(expired) == 0 {
+		return err
+	}
+
+	members := make([]interface{}, len(expired))
+	for i, key := range expired {
+		members[i] = key
+	}
+	return r.withRetry(func(ctx context.Context) error {
+		_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZRem(ctx, expiryIndex, members...)
+			pipe.ZRem(ctx, StoredIndex, members...)
+			return nil
+		})
+		return err
+	})
+}
+
+// countKeys counts the listing keys with SCAN, one call per step
+func (r *RedisClient) countKeys() (int64, error) {
+	var count int64
+	var cursor uint64
+	for {
+		var keys []string
+		var next uint64
+		err := r.withRetry(func(ctx context.Context) error {
+			var err error
+			keys, next, err = r.client.Scan(ctx, cursor, ListingKeyPrefix+"*", 1000).Result()
+			return err
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan listings: %w", err)
+		}
+
+		count += int64(len(keys))
+		if next == 0 {
+			return count, nil
+		}
+		cursor = next
+	}
+}
+
+// All returns the values of all stored listings
+func (r *RedisClient) All() ([]string, error) {
+	var values []string
+
+	iter := r.client.Scan(r.ctx, 0, ListingKeyPrefix+"*", 100).Iterator()
+	for iter.Next(r.ctx) {
+		value, err := r.Get(iter.Val())
+		if err == redis.Nil {
+			continue // Expired since the scan
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", iter.Val(), err)
+		}
+		values = append(values, value)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan listings: %w", err)
+	}
+	return values, nil
+}
+
+// ScanListings returns the listings of one SCAN step from the cursor, fetched
+// with a single MGET. Keys that expired since the scan and unreadable records
+// are skipped, so a page may hold fewer than count listings or none at all.
+func (r *RedisClient) ScanListings(cursor uint64, count int64) ([]*models.Listing, uint64, error) {
+	var keys []string
+	var next uint64
+	err := r.withRetry(func(ctx context.Context) error {
+		var err error
+		keys, next, err = r.client.Scan(ctx, cursor, ListingKeyPrefix+"*", count).Result()
+		return err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to scan listings: %w", err)
+	}
+
+	values, err := r.GetMany(keys)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get scanned listings: %w", err)
+	}
+
+	listings := make([]*models.Listing, 0, len(values))
+	for _, key := range keys {
+		value, ok := values[key]
+		if !ok {
+			continue // Expired since the scan
+		}
+
+		listing, err := models.FromJSON([]byte(value))
+		if err != nil {
+			log.Printf("Skipping unreadable listing %s: %v", key, err)
+			continue
+		}
+		listings = append(listings, listing)
+	}
+	return listings, next, nil
+}
+
+// withRetry runs a Redis operation under the per-call timeout, retrying
+// transient connection errors with backoff until the client's context is done
+func (r *RedisClient) withRetry(op func(ctx context.Context) error) error {
+	backoff := retryBackoff
+
+	var err error
+	for attempt := 1; attempt <= retryAttempts; attempt++ {
+		if ctxErr := r.ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		ctx, cancel := r.opContext()
+		err = op(ctx)
+		cancel()
+		if err == nil || !IsTransient(err) {
+			return err
+		}
+
+		if attempt < retryAttempts {
+			select {
+			case <-time.After(backoff):
+			case <-r.ctx.Done():
+				return r.ctx.Err()
+			}
+			backoff *= 2
+		}
+	}
+
+	return err
+}
+
+// opContext derives the context of a single call from the client's context
+func (r *RedisClient) opContext() (context.Context, context.CancelFunc) {
+	if r.timeout <= 0 {
+		return context.WithCancel(r.ctx)
+	}
+	return context.WithTimeout(r.ctx, r.timeout)
+}
+
+// IsTransient reports whether the error is a connection problem that may go away,
+// as opposed to a logical error like a missing key. A cancelled call or one
+// that ran out of its time is not transient: retrying it would outlast the
+// caller, and a save that timed out counts as failed. context.DeadlineExceeded
+// is checked first because it also satisfies net.Error.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// compressValue gzips a value
+func compressValue(value string) (string, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+
+	_, err := writer.Write([]byte(value))
+	if err != nil {
+		return "", err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// decompressValue gunzips a value written by compressValue
+func decompressValue(value string) (string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader([]byte(value)))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress value: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress value: %w", err)
+	}
+
+	return string(data), nil
 }
 
 // Close closes the Redis connection
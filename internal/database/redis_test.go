@@ -0,0 +1,302 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"avito-parser/internal/clock"
+	"avito-parser/internal/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newTestRedis starts an in-memory Redis server and connects a client to it
+func newTestRedis(t *testing.T) (*RedisClient, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client, err := NewRedisClientWithTimeout(server.Host(), server.Port(), "", 0, false, false, time.Second)
+	if err != nil {
+		t.Fatalf("NewRedisClientWithTimeout() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func TestRedisDeleteWhereCleansIndexes(t *testing.T) {
+	client, server := newTestRedis(t)
+
+	saved := []*models.Listing{
+		{ID: "listing_1", PriceRUB: 90000, Signature: "sig1", ImageHash: "img1"},
+		{ID: "listing_2", PriceRUB: 10000, Signature: "sig1", ImageHash: "img2"},
+	}
+	for _, listing := range saved {
+		data, err := listing.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Set(listing.ID, string(data), time.Hour); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		client.Set(FirstSeenKey(listing.ID), "2024-01-01T00:00:00Z", 0)
+		client.Set(DeadLetterKey(listing.ID), "failed", 0)
+		client.AddToIndex(DeadLetterIndex, listing.ID, time.Hour)
+		client.AddToIndex(FingerprintIndex(listing.Signature), listing.ID, time.Hour)
+		client.AddToIndex(ImageIndex(listing.ImageHash), listing.ID, time.Hour)
+		client.ZAdd(StoredIndex, map[string]float64{listing.ID: 1})
+	}
+
+	deleted, err := client.DeleteWhere(priceAbove(30000))
+	if err != nil {
+		t.Fatalf("DeleteWhere() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("DeleteWhere() = %d, want 1", deleted)
+	}
+
+	for _, key := range []string{"listing_1", FirstSeenKey("listing_1"), DeadLetterKey("listing_1"), ImageIndex("img1")} {
+		if server.Exists(key) {
+			t.Errorf("%s was kept", key)
+		}
+	}
+	for _, key := range []string{"listing_2", FirstSeenKey("listing_2"), DeadLetterKey("listing_2")} {
+		if !server.Exists(key) {
+			t.Errorf("%s of the kept listing was deleted", key)
+		}
+	}
+
+	sets := map[string][]string{
+		DeadLetterIndex:          {"listing_2"},
+		FingerprintIndex("sig1"): {"listing_2"},
+		ImageIndex("img2"):       {"listing_2"},
+	}
+	for set, want := range sets {
+		members, err := server.Members(set)
+		if err != nil || len(members) != len(want) || members[0] != want[0] {
+			t.Errorf("members of %s = %v, %v, want %v", set, members, err, want)
+		}
+	}
+	for _, index := range []string{StoredIndex, expiryIndex} {
+		members, err := server.ZMembers(index)
+		if err != nil || len(members) != 1 || members[0] != "listing_2" {
+			t.Errorf("members of %s = %v, %v, want [listing_2]", index, members, err)
+		}
+	}
+
+	if count, err := client.Count(); err != nil || count != 1 {
+		t.Errorf("Count() = %d, %v, want 1", count, err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"missing key", redis.Nil, false},
+		{"server error", errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"connection closed", io.EOF, true},
+		{"cut reply", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"cancelled", context.Canceled, false},
+		{"timed out", context.DeadlineExceeded, false},
+		{"wrapped timeout", fmt.Errorf("failed to save: %w", context.DeadlineExceeded), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// slowRedis accepts connections and reads commands without ever answering,
+// counting the connections made to it
+func slowRedis(t *testing.T) (addr string, conns *atomic.Int64) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	conns = new(atomic.Int64)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns.Add(1)
+			go io.Copy(io.Discard, conn)
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	return listener.Addr().String(), conns
+}
+
+func TestRedisCallsEndWithTheirContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  time.Duration
+		want    error
+	}{
+		{"cancelled context", 0, 50 * time.Millisecond, context.Canceled},
+		{"op timeout", 50 * time.Millisecond, 0, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, conns := slowRedis(t)
+			client := &RedisClient{
+				client:  redis.NewClient(&redis.Options{Addr: addr}),
+				ctx:     context.Background(),
+				timeout: tt.timeout,
+			}
+			defer client.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel > 0 {
+				time.AfterFunc(tt.cancel, cancel)
+			}
+
+			start := time.Now()
+			_, err := client.WithContext(ctx).Get("listing_1")
+			elapsed := time.Since(start)
+
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Get() error = %v, want %v", err, tt.want)
+			}
+			if elapsed > time.Second {
+				t.Errorf("Get() took %v, want it to end with its context", elapsed)
+			}
+			if n := conns.Load(); n != 1 {
+				t.Errorf("Get() made %d connections, want a single attempt", n)
+			}
+		})
+	}
+}
+
+func TestRedisScanListings(t *testing.T) {
+	client, server := newTestRedis(t)
+
+	const stored = 25
+	for i := 1; i <= stored; i++ {
+		listing := &models.Listing{ID: fmt.Sprintf("listing_%02d", i)}
+		data, err := listing.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		server.Set(listing.ID, string(data))
+		server.Set(FirstSeenKey(listing.ID), "2024-01-01T00:00:00Z")
+	}
+	server.Set("listing_broken", "{not json")
+
+	seen := make(map[string]int)
+	var cursor uint64
+	steps := 0
+	for {
+		steps++
+		if steps > 3*stored {
+			t.Fatal("ScanListings() never returned to cursor 0")
+		}
+
+		listings, next, err := client.ScanListings(cursor, 10)
+		if err != nil {
+			t.Fatalf("ScanListings(%d) error = %v", cursor, err)
+		}
+		for _, listing := range listings {
+			seen[listing.ID]++
+		}
+
+		// Change the keys between the steps: one listing is added and one
+		// that wasn't returned yet is deleted
+		if steps == 1 {
+			server.Set("listing_00", `{"id":"listing_00"}`)
+			server.Del(fmt.Sprintf("listing_%02d", stored))
+			if seen[fmt.Sprintf("listing_%02d", stored)] > 0 {
+				t.Fatal("the listing to delete was already returned, the test needs another one")
+			}
+		}
+
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+
+	if steps < 3 {
+		t.Errorf("ScanListings() took %d steps, want pages of about 10 listings", steps)
+	}
+	for i := 1; i < stored; i++ {
+		if id := fmt.Sprintf("listing_%02d", i); seen[id] == 0 {
+			t.Errorf("%s, stored for the whole scan, was not returned", id)
+		}
+	}
+	for _, id := range []string{fmt.Sprintf("listing_%02d", stored), "listing_broken"} {
+		if seen[id] > 0 {
+			t.Errorf("%s was returned", id)
+		}
+	}
+	for id := range seen {
+		if !strings.HasPrefix(id, ListingKeyPrefix) {
+			t.Errorf("ScanListings() returned %s, which isn't a listing", id)
+		}
+	}
+}
+
+func TestRedisCountFollowsSavesDeletesAndExpiry(t *testing.T) {
+	client, server := newTestRedis(t)
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	client.clock = clk
+
+	// advance moves the clock of the index and the TTLs of the server together.
+	// The index keeps whole seconds, so steps land a second past an expiry.
+	advance := func(d time.Duration) {
+		clk.Advance(d)
+		server.FastForward(d)
+	}
+	wantCount := func(step string, want int64) {
+		t.Helper()
+		if count, err := client.Count(); err != nil || count != want {
+			t.Errorf("Count() after %s = %d, %v, want %d", step, count, err, want)
+		}
+	}
+
+	client.Set("listing_1", `{"id":"listing_1"}`, time.Hour)
+	client.Set("listing_2", `{"id":"listing_2"}`, time.Hour)
+	client.Set("listing_3", `{"id":"listing_3"}`, 2*time.Hour)
+	client.Set("listing_4", `{"id":"listing_4"}`, 0)
+	if _, err := client.SetMany(map[string]string{"listing_5": `{"id":"listing_5"}`}, time.Hour); err != nil {
+		t.Fatalf("SetMany() error = %v", err)
+	}
+	client.Set(FirstSeenKey("listing_1"), "2024-05-01T12:00:00Z", 0)
+	wantCount("saves", 5)
+
+	client.Delete("listing_2")
+	wantCount("a delete", 4)
+
+	client.Expire("listing_1", 3*time.Hour)
+	advance(time.Hour + time.Second)
+	wantCount("listing_5 expired", 3)
+
+	advance(time.Hour)
+	wantCount("listing_3 expired", 2)
+
+	client.Set("listing_3", `{"id":"listing_3"}`, time.Hour)
+	wantCount("a save of an expired listing", 3)
+
+	advance(time.Hour + time.Second)
+	wantCount("listing_1 and listing_3 expired", 1)
+}
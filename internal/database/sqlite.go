@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"avito-parser/internal/models"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore keeps values in a single table of an SQLite database. It is
+// meant as an archive next to Redis: expiration is ignored, so listings stay
+// after they expire from Redis.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens the database file, creating it and its table if needed
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+	// SQLite allows a single writer, one connection avoids "database is locked"
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS kv (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create SQLite table: %w", err)
+	}
+
+	log.Printf("Using SQLite store in %s", path)
+	return &SQLiteStore{db: db}, nil
+}
+
+// listingKeys matches the keys of stored listings. GLOB is used instead of
+// LIKE because the underscore of the prefix is a LIKE wildcard.
+const listingKeys = "key GLOB '" + ListingKeyPrefix + "*'"
+
+// Set inserts or replaces the value. Expiration is ignored.
+func (s *SQLiteStore) Set(key, value string, expiration time.Duration) error {
+	_, err := s.db.Exec(`INSERT INTO kv (key, value) VALUES (?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value`, key, value)
+	return err
+}
+
+// Get reads the value of a key
+func (s *SQLiteStore) Get(key string) (string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM kv WHERE key = ?`, key).Scan(&value)
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// Exists checks if the key is stored
+func (s *SQLiteStore) Exists(key string) (bool, error) {
+	var found int
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM kv WHERE key = ?)`, key).Scan(&found)
+	if err != nil {
+		return false, err
+	}
+	return found == 1, nil
+}
+
+// Delete removes the key
+func (s *SQLiteStore) Delete(key string) error {
+	_, err := s.db.Exec(`DELETE FROM kv WHERE key = ?`, key)
+	return err
+}
+
+// All returns the values of all stored listings
+func (s *SQLiteStore) All() ([]string, error) {
+	rows, err := s.db.Query(`SELECT value FROM kv WHERE ` + listingKeys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+// Count counts the stored listings
+func (s *SQLiteStore) Count() (int64, error) {
+	var count int64
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM kv WHERE ` + listingKeys).Scan(&count)
+	return count, err
+}
+
+// ScanListings reads count listings after the cursor, the rowid of the last
+// listing of the previous page. Updating a listing keeps its rowid, so pages
+// don't repeat or skip listings that change while scanning.
+func (s *SQLiteStore) ScanListings(cursor uint64, count int64) ([]*models.Listing, uint64, error) {
+	if count <= 0 {
+		count = 100
+	}
+
+	rows, err := s.db.Query(`SELECT rowid, key, value FROM kv WHERE rowid > ? AND `+listingKeys+`
+		ORDER BY rowid LIMIT ?`, cursor, count)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var listings []*models.Listing
+	var last uint64
+	var read int64
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&last, &key, &value); err != nil {
+			return nil, 0, err
+		}
+		read++
+
+		listing, err := models.FromJSON([]byte(value))
+		if err != nil {
+			log.Printf("Skipping unreadable listing %s: %v", key, err)
+			continue
+		}
+		listings = append(listings, listing)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// The cursor is back at 0 after the last page, like Redis SCAN
+	if read < count {
+		last = 0
+	}
+	return listings, last, nil
+}
+
+// DeleteWhere deletes the listings pred matches with the keys kept about them
+func (s *SQLiteStore) DeleteWhere(pred func(*models.Listing) bool) (int, error) {
+	return deleteWhere(s, pred, func(listing *models.Listing) error {
+		return deleteListingKeys(s, listing)
+	})
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
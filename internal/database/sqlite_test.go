@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "listings.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStore(t *testing.T) {
+	store := newTestSQLiteStore(t)
+
+	if err := store.Set("listing_1", `{"id":"1"}`, time.Second); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("listing_1", `{"id":"1","title":"updated"}`, 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := store.Set("first_seen:1", "1700000000", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	value, err := store.Get("listing_1")
+	if err != nil || value != `{"id":"1","title":"updated"}` {
+		t.Errorf("Get() = %q, %v, want the updated value", value, err)
+	}
+	if _, err := store.Get("listing_2"); err == nil {
+		t.Error("Get() of a missing key error = nil")
+	}
+
+	for key, want := range map[string]bool{"listing_1": true, "first_seen:1": true, "listing_2": false} {
+		if exists, err := store.Exists(key); err != nil || exists != want {
+			t.Errorf("Exists(%q) = %v, %v, want %v", key, exists, err, want)
+		}
+	}
+
+	values, err := store.All()
+	if err != nil || len(values) != 1 {
+		t.Errorf("All() = %v, %v, want only the listing", values, err)
+	}
+	if count, err := store.Count(); err != nil || count != 1 {
+		t.Errorf("Count() = %d, %v, want 1", count, err)
+	}
+
+	if err := store.Delete("listing_1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if exists, _ := store.Exists("listing_1"); exists {
+		t.Error("Exists() after Delete = true")
+	}
+}
+
+func TestSQLiteStoreScanListings(t *testing.T) {
+	store := newTestSQLiteStore(t)
+	for i := 1; i <= 7; i++ {
+		listing := &models.Listing{ID: fmt.Sprint(i), Title: "flat"}
+		data, err := listing.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Set(fmt.Sprintf("listing_%d", i), string(data), 0); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	store.Set("first_seen:1", "1700000000", 0)
+	store.Set("listing_bad", "not json", 0)
+
+	for _, count := range []int64{1, 3, 7, 8, 100} {
+		var ids []string
+		var cursor uint64
+		for pages := 0; ; pages++ {
+			if pages > 20 {
+				t.Fatalf("count %d: scan did not end", count)
+			}
+			listings, next, err := store.ScanListings(cursor, count)
+			if err != nil {
+				t.Fatalf("count %d: ScanListings() error = %v", count, err)
+			}
+			for _, listing := range listings {
+				ids = append(ids, listing.ID)
+			}
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+
+		sort.Strings(ids)
+		if fmt.Sprint(ids) != "[1 2 3 4 5 6 7]" {
+			t.Errorf("count %d: scanned ids %v, want each listing once", count, ids)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+// ListingKeyPrefix is the key prefix of stored listings
+const ListingKeyPrefix = "listing_"
+
+// StoredIndex orders the ids of stored listings by their first-seen time. It
+// is kept when stored listings are capped.
+const StoredIndex = "index:first_seen"
+
+// DeadLetterIndex holds the ids of listings that kept failing to save; the
+// last error of each is kept under DeadLetterKey for inspection
+const DeadLetterIndex = "dead_letter"
+
+// FirstSeenKey is where the first-seen time of a listing is kept
+func FirstSeenKey(id string) string {
+	return "first_seen:" + id
+}
+
+// DeadLetterKey is where the last save error of a dead-lettered listing is kept
+func DeadLetterKey(id string) string {
+	return "dead_letter:" + id
+}
+
+// FingerprintIndex holds the ids of listings sharing a fingerprint
+func FingerprintIndex(signature string) string {
+	return "fingerprint:" + signature
+}
+
+// ImageIndex holds the ids of listings sharing a first photo
+func ImageIndex(hash string) string {
+	return "image:" + hash
+}
+
+// Store is a key-value storage for serialized listings
+type Store interface {
+	Set(key, value string, expiration time.Duration) error
+	Get(key string) (string, error)
+	Exists(key string) (bool, error)
+	Delete(key string) error
+	// All returns the values of all stored listings
+	All() ([]string, error)
+	// DeleteWhere deletes the stored listings pred matches together with the
+	// keys and index entries kept about them, returning how many were deleted
+	DeleteWhere(pred func(*models.Listing) bool) (int, error)
+	Close() error
+}
+
+// Indexer is implemented by stores that can keep secondary indexes of listing ids
+type Indexer interface {
+	// AddToIndex adds the member to the index set, refreshing its expiration
+	AddToIndex(index, member string, expiration time.Duration) error
+	// IndexMembers returns all members of the index set
+	IndexMembers(index string) ([]string, error)
+}
+
+// SortedIndexer is implemented by stores that can keep members ordered by a score
+type SortedIndexer interface {
+	// ZAdd adds the members to the sorted index or updates their scores
+	ZAdd(index string, scores map[string]float64) error
+	// ZRange returns the members from start to stop by ascending score, both inclusive
+	ZRange(index string, start, stop int64) ([]string, error)
+	// ZRem removes the members from the sorted index
+	ZRem(index string, members ...string) error
+	// ZCard returns how many members the sorted index has
+	ZCard(index string) (int64, error)
+}
+
+// Expirer is implemented by stores whose keys expire
+type Expirer interface {
+	// Expire resets the time to live of an existing key
+	Expire(key string, ttl time.Duration) error
+}
+
+// BatchStore is implemented by stores that can read and write many keys in one round trip
+type BatchStore interface {
+	// GetMany returns the values of the keys that exist
+	GetMany(keys []string) (map[string]string, error)
+	// SetMany writes all values, returning the keys that failed with their errors
+	SetMany(values map[string]string, expiration time.Duration) (map[string]error, error)
+}
+
+// Scanner is implemented by stores that can page through the stored listings
+// without loading them all into memory
+type Scanner interface {
+	// ScanListings returns about count listings starting at the cursor, 0 for
+	// the first page, and the cursor of the next page, 0 after the last one
+	ScanListings(cursor uint64, count int64) ([]*models.Listing, uint64, error)
+}
+
+// Counter is implemented by stores that can count the stored listings without reading them
+type Counter interface {
+	// Count returns how many listings are stored
+	Count() (int64, error)
+}
+
+// Count returns how many listings the store holds, loading them all only when
+// the store can't count them itself
+func Count(store Store) (int64, error) {
+	if counter, ok := store.(Counter); ok {
+		return counter.Count()
+	}
+
+	values, err := store.All()
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(values)), nil
+}
+
+// ContextBinder is implemented by stores whose calls can be cancelled
+type ContextBinder interface {
+	// WithContext returns the store with its calls bound to ctx
+	WithContext(ctx context.Context) Store
+}
+
+// WithContext binds the store's calls to ctx when it supports cancellation
+func WithContext(ctx context.Context, store Store) Store {
+	if binder, ok := store.(ContextBinder); ok {
+		return binder.WithContext(ctx)
+	}
+	return store
+}
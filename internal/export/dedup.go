@@ -0,0 +1,110 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// dedupEntry is what the duplicate report keeps of a listing
+type dedupEntry struct {
+	ID    string
+	Price string
+	URL   string
+}
+
+// duplicateCluster is a group of stored listings sharing a fingerprint or a photo
+type duplicateCluster struct {
+	// Kind is "fingerprint" or "image"
+	Kind     string
+	Key      string
+	Listings []dedupEntry
+}
+
+// dedupClusterer groups listings by fingerprint and by image hash
+type dedupClusterer struct {
+	fingerprints map[string][]dedupEntry
+	images       map[string][]dedupEntry
+}
+
+// newDedupClusterer creates an empty clusterer
+func newDedupClusterer() *dedupClusterer {
+	return &dedupClusterer{
+		fingerprints: make(map[string][]dedupEntry),
+		images:       make(map[string][]dedupEntry),
+	}
+}
+
+// Add files a listing under its fingerprint and photo, whichever it has
+func (c *dedupClusterer) Add(listing *models.Listing) {
+	entry := dedupEntry{ID: listing.ID, Price: listing.Price, URL: listing.URL}
+	if listing.Signature != "" {
+		c.fingerprints[listing.Signature] = append(c.fingerprints[listing.Signature], entry)
+	}
+	if listing.ImageHash != "" {
+		c.images[listing.ImageHash] = append(c.images[listing.ImageHash], entry)
+	}
+}
+
+// Clusters returns the groups of more than one listing, largest first
+func (c *dedupClusterer) Clusters() []duplicateCluster {
+	var clusters []duplicateCluster
+	for _, group := range []struct {
+		kind    string
+		entries map[string][]dedupEntry
+	}{
+		{"fingerprint", c.fingerprints},
+		{"image", c.images},
+	} {
+		for key, entries := range group.entries {
+			if len(entries) < 2 {
+				continue
+			}
+			sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+			clusters = append(clusters, duplicateCluster{Kind: group.kind, Key: key, Listings: entries})
+		}
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i].Listings) != len(clusters[j].Listings) {
+			return len(clusters[i].Listings) > len(clusters[j].Listings)
+		}
+		if clusters[i].Kind != clusters[j].Kind {
+			return clusters[i].Kind < clusters[j].Kind
+		}
+		return clusters[i].Key < clusters[j].Key
+	})
+	return clusters
+}
+
+// DedupReport reads the stored listings page by page and writes the groups of
+// likely duplicates, listings sharing a fingerprint or a first photo, to w.
+// Only ids, prices and URLs are kept in memory. It returns how many groups were found.
+func DedupReport(w io.Writer, store database.Store) (int, error) {
+	clusterer := newDedupClusterer()
+	err := Each(store, func(listing *models.Listing) error {
+		clusterer.Add(listing)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	clusters := clusterer.Clusters()
+	for _, cluster := range clusters {
+		_, err := fmt.Fprintf(w, "%s %s (%d listings)\n", cluster.Kind, cluster.Key, len(cluster.Listings))
+		if err != nil {
+			return 0, err
+		}
+		for _, entry := range cluster.Listings {
+			_, err := fmt.Fprintf(w, "  %s\t%s\t%s\n", entry.ID, entry.Price, entry.URL)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(clusters), nil
+}
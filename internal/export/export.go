@@ -0,0 +1,141 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// largeDatasetSize is the listing count above which JSONL is recommended over a JSON array
+const largeDatasetSize = 5000
+
+// scanPageSize is how many listings are requested per page when streaming
+const scanPageSize = 500
+
+// Load reads and decodes all stored listings, skipping unreadable ones
+func Load(store database.Store) ([]*models.Listing, error) {
+	values, err := store.All()
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]*models.Listing, 0, len(values))
+	for _, value := range values {
+		listing, err := models.FromJSON([]byte(value))
+		if err != nil {
+			log.Printf("Skipping unreadable listing: %v", err)
+			continue
+		}
+		listings = append(listings, listing)
+	}
+	return listings, nil
+}
+
+// Each calls fn for every stored listing. Stores that can scan are read page by
+// page so the whole dataset is never held in memory; others are loaded at once.
+// Listings a scan returns more than once are passed to fn only the first time.
+func Each(store database.Store, fn func(*models.Listing) error) error {
+	scanner, ok := store.(database.Scanner)
+	if !ok {
+		listings, err := Load(store)
+		if err != nil {
+			return err
+		}
+		for _, listing := range listings {
+			if err := fn(listing); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	visited := make(map[string]bool)
+	var cursor uint64
+	for {
+		listings, next, err := scanner.ScanListings(cursor, scanPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, listing := range listings {
+			if visited[listing.ID] {
+				continue
+			}
+			visited[listing.ID] = true
+			if err := fn(listing); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// StreamJSONL writes every stored listing as one JSON object per line, page by
+// page, and returns how many were written
+func StreamJSONL(w io.Writer, store database.Store) (int, error) {
+	written := 0
+	err := Each(store, func(listing *models.Listing) error {
+		err := JSONL(w, []*models.Listing{listing})
+		if err == nil {
+			written++
+		}
+		return err
+	})
+	return written, err
+}
+
+// Write exports listings in the given format: "json" or "jsonl"
+func Write(w io.Writer, format string, listings []*models.Listing, indent int) error {
+	switch format {
+	case "json":
+		return JSON(w, listings, indent)
+	case "jsonl":
+		return JSONL(w, listings)
+	}
+	return fmt.Errorf("unknown export format: %s", format)
+}
+
+// JSON writes listings as a single JSON array indented by the given number of spaces
+func JSON(w io.Writer, listings []*models.Listing, indent int) error {
+	if len(listings) > largeDatasetSize {
+		log.Printf("Exporting %d listings as one JSON array, consider -export jsonl for large datasets", len(listings))
+	}
+
+	// Keep an empty export a valid array rather than null
+	if listings == nil {
+		listings = []*models.Listing{}
+	}
+
+	data, err := json.MarshalIndent(listings, "", strings.Repeat(" ", indent))
+	if err != nil {
+		return fmt.Errorf("failed to marshal listings: %w", err)
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// JSONL writes one listing JSON object per line
+func JSONL(w io.Writer, listings []*models.Listing) error {
+	for _, listing := range listings {
+		data, err := listing.ToJSON()
+		if err != nil {
+			return fmt.Errorf("failed to marshal listing %s: %w", listing.ID, err)
+		}
+
+		_, err = w.Write(append(data, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
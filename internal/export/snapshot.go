@@ -0,0 +1,71 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"avito-parser/internal/database"
+)
+
+// snapshotTimeFormat is the timestamp in snapshot file names
+const snapshotTimeFormat = "20060102T150405"
+
+// Snapshot writes all stored listings to <dir>/snapshot-<timestamp>.jsonl and
+// returns the file path. The file is written under a temporary name first so
+// a failed write never leaves a truncated snapshot behind.
+func Snapshot(store database.Store, dir string, now time.Time) (string, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot dir: %w", err)
+	}
+
+	path := filepath.Join(dir, "snapshot-"+now.Format(snapshotTimeFormat)+".jsonl")
+	tmp, err := os.CreateTemp(dir, ".snapshot-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = StreamJSONL(tmp, store)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return "", fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return path, nil
+}
+
+// PruneSnapshots removes snapshots in dir whose timestamp is more than
+// retention before now and returns how many were removed. Files that don't
+// look like snapshots are left alone.
+func PruneSnapshots(dir string, retention time.Duration, now time.Time) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "snapshot-*.jsonl"))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, path := range paths {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "snapshot-"), ".jsonl")
+		takenAt, err := time.ParseInLocation(snapshotTimeFormat, stamp, now.Location())
+		if err != nil || now.Sub(takenAt) <= retention {
+			continue
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove snapshot %s: %w", path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
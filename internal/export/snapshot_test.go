@@ -0,0 +1,115 @@
+package export
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+func TestSnapshot(t *testing.T) {
+	store, err := database.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"listing_1", "listing_2", "listing_3"} {
+		data, err := (&models.Listing{ID: id, Title: "Flat"}).ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Set(id, string(data), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	now := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	path, err := Snapshot(store, dir, now)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if want := filepath.Join(dir, "snapshot-20240501T123015.jsonl"); path != want {
+		t.Errorf("Snapshot() = %q, want %q", path, want)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		listing, err := models.FromJSON(scanner.Bytes())
+		if err != nil {
+			t.Fatalf("snapshot line %q: %v", scanner.Text(), err)
+		}
+		ids = append(ids, listing.ID)
+	}
+	sort.Strings(ids)
+	if len(ids) != 3 || ids[0] != "listing_1" || ids[2] != "listing_3" {
+		t.Errorf("snapshot holds %v, want the 3 stored listings", ids)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("snapshot dir holds %d files, want the temporary file gone", len(entries))
+	}
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		file    string
+		removed bool
+	}{
+		{"current", "snapshot-20240501T120000.jsonl", false},
+		{"within retention", "snapshot-20240430T130000.jsonl", false},
+		{"exactly at retention", "snapshot-20240430T120000.jsonl", false},
+		{"past retention", "snapshot-20240430T115959.jsonl", true},
+		{"long ago", "snapshot-20230101T000000.jsonl", true},
+		{"unparsable stamp", "snapshot-yesterday.jsonl", false},
+		{"other file", "listings-20230101T000000.jsonl", false},
+		{"other extension", "snapshot-20230101T000000.json", false},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		if err := os.WriteFile(filepath.Join(dir, tt.file), []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := PruneSnapshots(dir, 24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("PruneSnapshots() error = %v", err)
+	}
+
+	wantRemoved := 0
+	for _, tt := range tests {
+		_, err := os.Stat(filepath.Join(dir, tt.file))
+		if gone := os.IsNotExist(err); gone != tt.removed {
+			t.Errorf("%s: %s removed = %v, want %v", tt.name, tt.file, gone, tt.removed)
+		}
+		if tt.removed {
+			wantRemoved++
+		}
+	}
+	if removed != wantRemoved {
+		t.Errorf("PruneSnapshots() = %d, want %d", removed, wantRemoved)
+	}
+}
+
+func TestPruneSnapshotsMissingDir(t *testing.T) {
+	removed, err := PruneSnapshots(filepath.Join(t.TempDir(), "missing"), time.Hour, time.Now())
+	if err != nil || removed != 0 {
+		t.Errorf("PruneSnapshots() = %d, %v, want nothing to prune", removed, err)
+	}
+}
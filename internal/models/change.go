@@ -0,0 +1,106 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FieldChange is one changed field of a listing
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// ListingChange is an update of a tracked listing with the fields that changed
+type ListingChange struct {
+	Listing *Listing      `json:"listing"`
+	Fields  []FieldChange `json:"fields"`
+}
+
+// NewListingChange compares a stored listing with its fresh observation. Only
+// price, title and status are compared; timestamps and counters always change.
+// It returns nil when none of them did.
+func NewListingChange(old, updated *Listing) *ListingChange {
+	if old == nil || updated == nil {
+		return nil
+	}
+
+	changes := old.Changes(updated)
+
+	var fields []FieldChange
+	for _, field := range []string{"price", "title", "status"} {
+		if change, ok := changes[field]; ok {
+			fields = append(fields, FieldChange{Field: field, Old: change[0], New: change[1]})
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ListingChange{Listing: updated, Fields: fields}
+}
+
+// comparedFields returns the meaningful fields of a listing as text, keyed by
+// their JSON names. Timestamps, counters and derived values are left out.
+func (l *Listing) comparedFields() map[string]string {
+	return map[string]string{
+		"title":       l.Title,
+		"price":       l.Price,
+		"price_raw":   l.PriceRaw,
+		"currency":    l.Currency,
+		"url":         l.URL,
+		"location":    l.Location,
+		"description": l.Description,
+		"phone":       l.Phone,
+		"images":      strings.Join(l.Images, ", "),
+		"status":      statusOrActive(l.Status),
+		"tags":        strings.Join(l.Tags, ", "),
+		"bumped":      strconv.FormatBool(l.Bumped),
+	}
+}
+
+// Changes returns the meaningful fields that differ from l to other as
+// field -> [old, new], keyed by JSON names. Timestamps and observed counts are
+// ignored. A nil listing has all fields empty.
+func (l *Listing) Changes(other *Listing) map[string][2]string {
+	if l == nil {
+		l = &Listing{}
+	}
+	if other == nil {
+		other = &Listing{}
+	}
+
+	oldFields := l.comparedFields()
+	newFields := other.comparedFields()
+
+	changes := make(map[string][2]string)
+	for field, oldValue := range oldFields {
+		if newValue := newFields[field]; newValue != oldValue {
+			changes[field] = [2]string{oldValue, newValue}
+		}
+	}
+	return changes
+}
+
+// Equal reports whether the listings have the same meaningful fields
+func (l *Listing) Equal(other *Listing) bool {
+	return len(l.Changes(other)) == 0
+}
+
+// String renders the change like "price: 30 000 ₽ → 27 000 ₽; status: active → archived"
+func (c *ListingChange) String() string {
+	parts := make([]string, 0, len(c.Fields))
+	for _, field := range c.Fields {
+		parts = append(parts, field.Field+": "+field.Old+" → "+field.New)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// statusOrActive treats records saved before statuses were tracked as active
+func statusOrActive(status string) string {
+	if status == "" {
+		return StatusActive
+	}
+	return status
+}
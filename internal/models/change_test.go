@@ -0,0 +1,81 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewListingChange(t *testing.T) {
+	old := &Listing{
+		ID:            "listing_1",
+		Title:         "2-к. квартира, 54 м²",
+		Price:         "30 000 ₽",
+		Status:        StatusActive,
+		Phone:         "+7 900 000-00-00",
+		UpdatedAt:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		ObservedCount: 3,
+	}
+	with := func(change func(l *Listing)) *Listing {
+		l := *old
+		change(&l)
+		return &l
+	}
+
+	tests := []struct {
+		name    string
+		old     *Listing
+		updated *Listing
+		want    []FieldChange
+	}{
+		{"unchanged", old, with(func(l *Listing) {}), nil},
+		{"timestamps and counters", old, with(func(l *Listing) { l.UpdatedAt = l.UpdatedAt.Add(time.Hour); l.ObservedCount++ }), nil},
+		{"other fields", old, with(func(l *Listing) { l.Phone = "+7 911 111-11-11" }), nil},
+		{"empty status is active", old, with(func(l *Listing) { l.Status = "" }), nil},
+		{"price", old, with(func(l *Listing) { l.Price = "27 000 ₽" }), []FieldChange{{"price", "30 000 ₽", "27 000 ₽"}}},
+		{"status", old, with(func(l *Listing) { l.Status = StatusArchived }), []FieldChange{{"status", StatusActive, StatusArchived}}},
+		{
+			"in field order",
+			old,
+			with(func(l *Listing) {
+				l.Status = StatusRemoved
+				l.Title = "2-к. квартира, 55 м²"
+				l.Price = "31 000 ₽"
+			}),
+			[]FieldChange{{"price", "30 000 ₽", "31 000 ₽"}, {"title", "2-к. квартира, 54 м²", "2-к. квартира, 55 м²"}, {"status", StatusActive, StatusRemoved}},
+		},
+		{"no stored listing", nil, old, nil},
+		{"no update", old, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := NewListingChange(tt.old, tt.updated)
+			if tt.want == nil {
+				if change != nil {
+					t.Errorf("NewListingChange() = %s, want nil", change)
+				}
+				return
+			}
+			if change == nil {
+				t.Fatalf("NewListingChange() = nil, want %v", tt.want)
+			}
+			if !reflect.DeepEqual(change.Fields, tt.want) {
+				t.Errorf("NewListingChange() fields = %v, want %v", change.Fields, tt.want)
+			}
+			if change.Listing != tt.updated {
+				t.Error("NewListingChange() listing isn't the updated one")
+			}
+		})
+	}
+}
+
+func TestListingChangeString(t *testing.T) {
+	change := &ListingChange{Fields: []FieldChange{
+		{"price", "30 000 ₽", "27 000 ₽"},
+		{"status", StatusActive, StatusArchived},
+	}}
+	if want := "price: 30 000 ₽ → 27 000 ₽; status: active → archived"; change.String() != want {
+		t.Errorf("String() = %q, want %q", change.String(), want)
+	}
+}
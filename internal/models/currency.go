@@ -0,0 +1,71 @@
+package models
+
+import (
+	"math"
+	"strings"
+)
+
+// Currency codes detected in price texts
+const (
+	CurrencyRUB = "RUB"
+	CurrencyUSD = "USD"
+	CurrencyEUR = "EUR"
+)
+
+// currencyMarkers map lowercased price text fragments to their currency
+var currencyMarkers = []struct {
+	marker   string
+	currency string
+}{
+	{"₽", CurrencyRUB},
+	{"руб", CurrencyRUB},
+	{"$", CurrencyUSD},
+	{"usd", CurrencyUSD},
+	{"долл", CurrencyUSD},
+	{"€", CurrencyEUR},
+	{"eur", CurrencyEUR},
+	{"евро", CurrencyEUR},
+}
+
+// DetectCurrency returns the currency of a price text like "1 200 € в месяц",
+// an empty string when the text names none
+func DetectCurrency(price string) string {
+	text := strings.ToLower(price)
+	for _, m := range currencyMarkers {
+		if strings.Contains(text, m.marker) {
+			return m.currency
+		}
+	}
+	return ""
+}
+
+// ComputePriceRUB converts the price to rubles with rates given as rubles per
+// unit of a currency. It returns 0 when the price or the rate is unknown.
+func (l *Listing) ComputePriceRUB(rates map[string]float64) int64 {
+	price, ok := l.PriceValue()
+	if !ok {
+		return 0
+	}
+
+	if l.Currency == CurrencyRUB {
+		return price
+	}
+
+	rate, ok := rates[l.Currency]
+	if !ok || rate <= 0 {
+		return 0
+	}
+	return int64(math.Round(float64(price) * rate))
+}
+
+// RublePrice returns the price in rubles: PriceRUB, or the price itself when
+// its text names no currency. It is not ok for a foreign price without a rate.
+func (l *Listing) RublePrice() (int64, bool) {
+	if l.PriceRUB > 0 {
+		return l.PriceRUB, true
+	}
+	if l.Currency != "" {
+		return 0, false
+	}
+	return l.PriceValue()
+}
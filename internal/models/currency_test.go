@@ -0,0 +1,81 @@
+package models
+
+import "testing"
+
+func TestDetectCurrency(t *testing.T) {
+	tests := []struct {
+		price string
+		want  string
+	}{
+		{"45 000 ₽ в месяц", CurrencyRUB},
+		{"45 000 руб.", CurrencyRUB},
+		{"$500", CurrencyUSD},
+		{"500 USD в месяц", CurrencyUSD},
+		{"500 долларов", CurrencyUSD},
+		{"1 200 € в месяц", CurrencyEUR},
+		{"1 200 EUR", CurrencyEUR},
+		{"1 200 евро", CurrencyEUR},
+		{"45 000", ""},
+		{"Цена не указана", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectCurrency(tt.price); got != tt.want {
+			t.Errorf("DetectCurrency(%q) = %q, want %q", tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestComputePriceRUB(t *testing.T) {
+	rates := map[string]float64{CurrencyUSD: 90, CurrencyEUR: 98.5, "GBP": 0}
+
+	tests := []struct {
+		name     string
+		price    string
+		currency string
+		want     int64
+	}{
+		{"rubles", "45 000 ₽", CurrencyRUB, 45000},
+		{"dollars", "$500", CurrencyUSD, 45000},
+		{"euros rounded", "1 001 €", CurrencyEUR, 98599},
+		{"no rate", "500 CHF", "CHF", 0},
+		{"zero rate", "500 GBP", "GBP", 0},
+		{"no price", "Цена не указана", CurrencyUSD, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Listing{Price: tt.price, Currency: tt.currency}
+			if got := l.ComputePriceRUB(rates); got != tt.want {
+				t.Errorf("ComputePriceRUB() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRublePrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    string
+		currency string
+		priceRUB int64
+		want     int64
+		ok       bool
+	}{
+		{"converted", "$500", CurrencyUSD, 45000, 45000, true},
+		{"rubles", "45 000 ₽", CurrencyRUB, 45000, 45000, true},
+		{"no currency", "45 000", "", 0, 45000, true},
+		{"foreign without a rate", "$500", CurrencyUSD, 0, 0, false},
+		{"no price", "Цена не указана", "", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Listing{Price: tt.price, Currency: tt.currency, PriceRUB: tt.priceRUB}
+			got, ok := l.RublePrice()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("RublePrice() = %d, %v, want %d, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
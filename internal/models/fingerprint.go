@@ -0,0 +1,72 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"avito-parser/internal/util"
+)
+
+var (
+	roomsPattern = regexp.MustCompile(`(\d+)-к`)
+	areaPattern  = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*м²`)
+	floorPattern = regexp.MustCompile(`(\d+)/(\d+)\s*эт`)
+)
+
+// Fingerprint returns a source-independent signature of the apartment so the
+// same flat can be matched across sites. It is stored as Signature and built from:
+//   - rooms from the title ("2-к." -> 2, studios -> 0, unknown -> ?)
+//   - area from the title rounded to whole square meters
+//   - floor and total floors from the title ("5/10 эт.")
+//   - price rounded to the nearest 1000
+//   - the first part of the location before a comma, lowercased
+func (l *Listing) Fingerprint() string {
+	title := strings.ToLower(l.Title)
+
+	rooms := "?"
+	if match := roomsPattern.FindStringSubmatch(title); match != nil {
+		rooms = match[1]
+	} else if strings.Contains(title, "студия") {
+		rooms = "0"
+	}
+
+	area := "?"
+	if value, ok := l.Area(); ok {
+		area = strconv.Itoa(int(math.Round(value)))
+	}
+
+	floor := "?"
+	if match := floorPattern.FindStringSubmatch(title); match != nil {
+		floor = match[1] + "/" + match[2]
+	}
+
+	price := "?"
+	if value, ok := priceDigits(l.Price); ok {
+		price = strconv.FormatInt((value+500)/1000*1000, 10)
+	}
+
+	location, _, _ := strings.Cut(strings.ToLower(l.Location), ",")
+
+	signature := fmt.Sprintf("r%s|a%s|f%s|p%s|%s", rooms, area, floor, price, strings.TrimSpace(location))
+	sum := sha1.Sum([]byte(signature))
+	return hex.EncodeToString(sum[:8])
+}
+
+// PriceValue returns the numeric price, false when the price has no digits
+func (l *Listing) PriceValue() (int64, bool) {
+	return priceDigits(l.Price)
+}
+
+// priceDigits reads the number from a price text like "25 000 ₽ в месяц"
+func priceDigits(price string) (int64, bool) {
+	value, err := util.ParseRussianInt(price)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,62 @@
+package models
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	base := Listing{
+		Title:    "2-к. квартира, 54,6 м², 5/10 эт.",
+		Price:    "45 000 ₽ в месяц",
+		Location: "ул. Ленина, 10",
+	}
+	with := func(change func(l *Listing)) Listing {
+		l := base
+		change(&l)
+		return l
+	}
+
+	tests := []struct {
+		name    string
+		listing Listing
+		same    bool
+	}{
+		{"identical", base, true},
+		{"other id and url", with(func(l *Listing) { l.ID = "123"; l.URL = "https://example.com/1" }), true},
+		{"title case", with(func(l *Listing) { l.Title = "2-К. Квартира, 54,6 м², 5/10 эт." }), true},
+		{"area rounded", with(func(l *Listing) { l.Title = "2-к. квартира, 55 м², 5/10 эт." }), true},
+		{"price rounded", with(func(l *Listing) { l.Price = "45 400 ₽ в месяц" }), true},
+		{"price without spaces", with(func(l *Listing) { l.Price = "45000 ₽" }), true},
+		{"location after comma", with(func(l *Listing) { l.Location = "УЛ. ЛЕНИНА, 12" }), true},
+		{"rooms", with(func(l *Listing) { l.Title = "3-к. квартира, 54,6 м², 5/10 эт." }), false},
+		{"area", with(func(l *Listing) { l.Title = "2-к. квартира, 60 м², 5/10 эт." }), false},
+		{"floor", with(func(l *Listing) { l.Title = "2-к. квартира, 54,6 м², 6/10 эт." }), false},
+		{"price", with(func(l *Listing) { l.Price = "46 000 ₽ в месяц" }), false},
+		{"street", with(func(l *Listing) { l.Location = "ул. Мира, 10" }), false},
+		{"unknown price", with(func(l *Listing) { l.Price = "Цена не указана" }), false},
+	}
+
+	want := base.Fingerprint()
+	if len(want) != 16 {
+		t.Fatalf("Fingerprint() = %q, want 16 hex characters", want)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.listing.Fingerprint()
+			if (got == want) != tt.same {
+				t.Errorf("Fingerprint() = %q, base %q, want same = %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestFingerprintStudio(t *testing.T) {
+	studio := Listing{Title: "Квартира-студия, 25 м², 3/9 эт.", Price: "30 000 ₽"}
+	zeroRooms := Listing{Title: "0-к. квартира, 25 м², 3/9 эт.", Price: "30 000 ₽"}
+	unknown := Listing{Title: "Апартаменты, 25 м², 3/9 эт.", Price: "30 000 ₽"}
+
+	if studio.Fingerprint() != zeroRooms.Fingerprint() {
+		t.Errorf("studio fingerprint %q differs from 0 rooms %q", studio.Fingerprint(), zeroRooms.Fingerprint())
+	}
+	if studio.Fingerprint() == unknown.Fingerprint() {
+		t.Errorf("studio fingerprint %q equals unknown rooms", studio.Fingerprint())
+	}
+}
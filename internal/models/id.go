@@ -0,0 +1,79 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// IDStrategy decides which listings are considered the same record
+type IDStrategy interface {
+	ID(l *Listing) string
+}
+
+// ByAvitoID identifies listings by the numeric Avito id at the end of the listing URL
+type ByAvitoID struct{}
+
+// ByCanonicalURL identifies listings by their URL without query string and fragment
+type ByCanonicalURL struct{}
+
+// ByContentHash identifies listings by a hash of their visible content
+type ByContentHash struct{}
+
+// avitoIDPattern matches the numeric id Avito appends to listing paths
+var avitoIDPattern = regexp.MustCompile(`_(\d+)$`)
+
+// ID returns the id based on the Avito listing number, falling back to the canonical URL
+func (ByAvitoID) ID(l *Listing) string {
+	parsedURL, err := url.Parse(l.URL)
+	if err == nil {
+		match := avitoIDPattern.FindStringSubmatch(strings.TrimSuffix(parsedURL.Path, "/"))
+		if match != nil {
+			return "listing_" + match[1]
+		}
+	}
+	return ByCanonicalURL{}.ID(l)
+}
+
+// ID returns the id based on the canonical URL, falling back to the content hash
+func (ByCanonicalURL) ID(l *Listing) string {
+	if l.URL == "" {
+		return ByContentHash{}.ID(l)
+	}
+
+	parsedURL, err := url.Parse(l.URL)
+	if err != nil {
+		return "listing_" + strings.ReplaceAll(l.URL, "/", "_")
+	}
+
+	canonical := strings.ToLower(parsedURL.Host) + strings.TrimSuffix(parsedURL.Path, "/")
+	return "listing_" + strings.ReplaceAll(canonical, "/", "_")
+}
+
+// ID returns the id based on a hash of title, price and location
+func (ByContentHash) ID(l *Listing) string {
+	content := strings.Join([]string{
+		strings.ToLower(strings.TrimSpace(l.Title)),
+		strings.TrimSpace(l.Price),
+		strings.ToLower(strings.TrimSpace(l.Location)),
+	}, "|")
+
+	sum := sha1.Sum([]byte(content))
+	return "listing_hash_" + hex.EncodeToString(sum[:])
+}
+
+// IDStrategyByName resolves an ID strategy from its configuration name
+func IDStrategyByName(name string) (IDStrategy, error) {
+	switch name {
+	case "", "avito_id":
+		return ByAvitoID{}, nil
+	case "url":
+		return ByCanonicalURL{}, nil
+	case "content_hash":
+		return ByContentHash{}, nil
+	}
+	return nil, fmt.Errorf("unknown ID strategy: %s", name)
+}
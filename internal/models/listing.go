@@ -5,17 +5,56 @@ import (
 	"time"
 )
 
+// Listing statuses
+const (
+	StatusActive   = "active"
+	StatusArchived = "archived"
+	StatusRemoved  = "removed"
+)
+
 // Listing represents an apartment listing from Avito
 type Listing struct {
 	ID          string    `json:"id"`
 	Title       string    `json:"title"`
 	Price       string    `json:"price"`
+	PriceRaw    string    `json:"price_raw,omitempty"`
+	Currency    string    `json:"currency,omitempty"`
+	PriceRUB    int64     `json:"price_rub,omitempty"`
+	PricePerSqm int64     `json:"price_per_sqm,omitempty"`
 	URL         string    `json:"url"`
 	Location    string    `json:"location,omitempty"`
 	Description string    `json:"description,omitempty"`
+	Phone       string    `json:"phone,omitempty"`
 	Images      []string  `json:"images,omitempty"`
+	ImagePath   string    `json:"image_path,omitempty"`
+	ImageHash   string    `json:"image_hash,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Signature   string    `json:"fingerprint,omitempty"`
+	PostedAt    time.Time `json:"posted_at"`
+	Bumped      bool      `json:"bumped,omitempty"`
+	BumpedAt    time.Time `json:"bumped_at"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	// Metro is the nearest metro station shown on the card, MetroMinutes the
+	// walking time to it; both are empty outside metro cities
+	Metro        string `json:"metro,omitempty"`
+	MetroMinutes int    `json:"metro_minutes,omitempty"`
+	// ObservedCount is how many parsing cycles saw the listing, CreatedAt is the first of them
+	ObservedCount int `json:"observed_count"`
+	// PossibleRepost is set when the first photo was recently seen under another id
+	PossibleRepost bool `json:"possible_repost,omitempty"`
+	// AvitoNewBadge and AvitoPriceCutBadge are set when the card carries
+	// Avito's own "new" or "price reduced" badge
+	AvitoNewBadge      bool `json:"avito_new_badge,omitempty"`
+	AvitoPriceCutBadge bool `json:"avito_price_cut_badge,omitempty"`
+	// Tracked is set on listings of the watch list, checked every cycle on their own page
+	Tracked bool `json:"tracked,omitempty"`
+	// SourcePage and Position are where the listing was in the search results
+	// when last seen, 1-based; they change between cycles as ranking shifts
+	SourcePage int `json:"source_page,omitempty"`
+	Position   int `json:"position,omitempty"`
 }
 
 // ToJSON converts the listing to JSON string
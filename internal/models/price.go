@@ -0,0 +1,37 @@
+package models
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Area returns the apartment area in square meters from a title like "2-к. квартира, 54,5 м²"
+func (l *Listing) Area() (float64, bool) {
+	match := areaPattern.FindStringSubmatch(strings.ToLower(l.Title))
+	if match == nil {
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", "."), 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// ComputePricePerSqm returns the ruble price per square meter rounded to whole
+// rubles, 0 when the ruble price or the area is unknown. Currency and PriceRUB
+// must be set first.
+func (l *Listing) ComputePricePerSqm() int64 {
+	price, ok := l.RublePrice()
+	if !ok {
+		return 0
+	}
+
+	area, ok := l.Area()
+	if !ok {
+		return 0
+	}
+	return int64(math.Round(float64(price) / area))
+}
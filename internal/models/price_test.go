@@ -0,0 +1,33 @@
+package models
+
+import "testing"
+
+func TestComputePricePerSqm(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		price    string
+		currency string
+		priceRUB int64
+		want     int64
+	}{
+		{"whole area", "1-к. квартира, 40 м², 3/9 эт.", "40 000 ₽ в месяц", "", 0, 1000},
+		{"decimal comma", "2-к. квартира, 54,5 м², 5/10 эт.", "45 000 ₽", "", 0, 826},
+		{"decimal point", "2-к. квартира, 54.5 м², 5/10 эт.", "45 000 ₽", "", 0, 826},
+		{"rounded to rubles", "Студия, 30 м²", "25 000 ₽", "", 0, 833},
+		{"converted foreign price", "Студия, 30 м²", "$500", "USD", 45000, 1500},
+		{"foreign price without a rate", "Студия, 30 м²", "$500", "USD", 0, 0},
+		{"zero area", "Студия, 0 м²", "25 000 ₽", "", 0, 0},
+		{"no area", "Комната в общежитии", "25 000 ₽", "", 0, 0},
+		{"no price", "Студия, 30 м²", "Цена не указана", "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Listing{Title: tt.title, Price: tt.price, Currency: tt.currency, PriceRUB: tt.priceRUB}
+			if got := l.ComputePricePerSqm(); got != tt.want {
+				t.Errorf("ComputePricePerSqm() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// alwaysStoredFields are kept by every projection: the listing's identity and
+// the bookkeeping read back when the listing is seen again
+var alwaysStoredFields = []string{"id", "url", "status", "tracked", "created_at", "updated_at", "last_seen_at", "observed_count"}
+
+// listingFields maps the JSON names of the listing's fields to their index
+var listingFields = func() map[string]int {
+	fields := make(map[string]int)
+	listingType := reflect.TypeOf(Listing{})
+	for i := 0; i < listingType.NumField(); i++ {
+		name, _, _ := strings.Cut(listingType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// ValidateFields checks that every name is the JSON name of a listing field
+func ValidateFields(names []string) error {
+	for _, name := range names {
+		if _, ok := listingFields[name]; !ok {
+			return fmt.Errorf("unknown listing field %q", name)
+		}
+	}
+	return nil
+}
+
+// Project returns a copy of the listing with only the fields named by their
+// JSON names, plus the always stored ones; the others are left empty. Without
+// names the listing itself is returned.
+func (l *Listing) Project(names []string) *Listing {
+	if l == nil || len(names) == 0 {
+		return l
+	}
+
+	projected := &Listing{}
+	source := reflect.ValueOf(l).Elem()
+	target := reflect.ValueOf(projected).Elem()
+	for _, list := range [][]string{alwaysStoredFields, names} {
+		for _, name := range list {
+			if i, ok := listingFields[name]; ok {
+				target.Field(i).Set(source.Field(i))
+			}
+		}
+	}
+	return projected
+}
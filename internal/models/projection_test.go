@@ -0,0 +1,97 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateFields(t *testing.T) {
+	tests := []struct {
+		names   []string
+		wantErr bool
+	}{
+		{nil, false},
+		{[]string{"title", "price", "url"}, false},
+		{[]string{"fingerprint", "price_per_sqm"}, false},
+		{[]string{"Title"}, true},
+		{[]string{"title", "signature"}, true},
+		{[]string{""}, true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateFields(tt.names); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateFields(%q) error = %v, wantErr %v", tt.names, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	full := &Listing{
+		ID:            "listing_1",
+		Title:         "2-к. квартира, 54,6 м², 5/10 эт.",
+		Price:         "45 000 ₽ в месяц",
+		URL:           "https://www.avito.ru/chelyabinsk/kvartiry/flat_1",
+		Description:   "Светлая квартира",
+		Images:        []string{"https://00.img.avito.st/image/1.jpg"},
+		Tags:          []string{"Собственник"},
+		Status:        StatusActive,
+		CreatedAt:     now.Add(-time.Hour),
+		UpdatedAt:     now,
+		LastSeenAt:    now,
+		ObservedCount: 3,
+	}
+	kept := Listing{
+		ID:            full.ID,
+		URL:           full.URL,
+		Status:        full.Status,
+		CreatedAt:     full.CreatedAt,
+		UpdatedAt:     full.UpdatedAt,
+		LastSeenAt:    full.LastSeenAt,
+		ObservedCount: full.ObservedCount,
+	}
+	with := func(change func(l *Listing)) *Listing {
+		l := kept
+		change(&l)
+		return &l
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  *Listing
+	}{
+		{"no projection", nil, full},
+		{"title and price", []string{"title", "price"}, with(func(l *Listing) { l.Title = full.Title; l.Price = full.Price })},
+		{"slices", []string{"images", "tags"}, with(func(l *Listing) { l.Images = full.Images; l.Tags = full.Tags })},
+		{"only always stored", []string{"id", "url"}, &kept},
+		{"unknown names ignored", []string{"signature"}, &kept},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := full.Project(tt.names)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Project(%q) = %+v, want %+v", tt.names, got, tt.want)
+			}
+
+			// The projection reads back the same, the left out fields empty
+			data, err := got.ToJSON()
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			read, err := FromJSON(data)
+			if err != nil {
+				t.Fatalf("FromJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(read, tt.want) {
+				t.Errorf("FromJSON(ToJSON()) = %+v, want %+v", read, tt.want)
+			}
+		})
+	}
+
+	if got := (*Listing)(nil).Project([]string{"title"}); got != nil {
+		t.Errorf("Project() of nil = %+v, want nil", got)
+	}
+}
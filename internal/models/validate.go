@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Validate checks the fields every stored listing needs: an id, a title and an
+// absolute http(s) URL. The error names everything that is missing at once.
+func (l *Listing) Validate() error {
+	if l == nil {
+		return errors.New("listing is nil")
+	}
+
+	var problems []string
+	if strings.TrimSpace(l.ID) == "" {
+		problems = append(problems, "id is empty")
+	}
+	if strings.TrimSpace(l.Title) == "" {
+		problems = append(problems, "title is empty")
+	}
+	if !isListingURL(l.URL) {
+		problems = append(problems, fmt.Sprintf("url %q is not an absolute http(s) URL", l.URL))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid listing %s: %s", l.ID, strings.Join(problems, ", "))
+}
+
+// isListingURL reports whether the URL can point to a listing page
+func isListingURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.Host == "" {
+		return false
+	}
+	return parsedURL.Scheme == "http" || parsedURL.Scheme == "https"
+}
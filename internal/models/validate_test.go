@@ -0,0 +1,59 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Listing{
+		ID:    "listing_1",
+		Title: "2-к. квартира, 54,6 м², 5/10 эт.",
+		URL:   "https://www.avito.ru/chelyabinsk/kvartiry/flat_1",
+	}
+	with := func(change func(l *Listing)) *Listing {
+		l := valid
+		change(&l)
+		return &l
+	}
+
+	tests := []struct {
+		name    string
+		listing *Listing
+		// wantErr lists the parts the error must mention, none for a valid listing
+		wantErr []string
+	}{
+		{"valid", &valid, nil},
+		{"http URL", with(func(l *Listing) { l.URL = "http://www.avito.ru/1" }), nil},
+		{"nil", nil, []string{"nil"}},
+		{"no id", with(func(l *Listing) { l.ID = "" }), []string{"id is empty"}},
+		{"blank id", with(func(l *Listing) { l.ID = "  " }), []string{"id is empty"}},
+		{"no title", with(func(l *Listing) { l.Title = "" }), []string{"title is empty"}},
+		{"blank title", with(func(l *Listing) { l.Title = "\t\n" }), []string{"title is empty"}},
+		{"no URL", with(func(l *Listing) { l.URL = "" }), []string{`url ""`}},
+		{"relative URL", with(func(l *Listing) { l.URL = "/chelyabinsk/kvartiry/flat_1" }), []string{"url"}},
+		{"other scheme", with(func(l *Listing) { l.URL = "ftp://www.avito.ru/1" }), []string{"url"}},
+		{"unparsable URL", with(func(l *Listing) { l.URL = "https://www.avito.ru/%zz" }), []string{"url"}},
+		{"everything missing", &Listing{}, []string{"id is empty", "title is empty", "url"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.listing.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want one mentioning %q", tt.wantErr)
+			}
+			for _, part := range tt.wantErr {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("Validate() error = %v, want it to mention %q", err, part)
+				}
+			}
+		})
+	}
+}
@@ -1,15 +1,21 @@
 package parser
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
+	"avito-parser/internal/clock"
 	"avito-parser/internal/database"
+	"avito-parser/internal/export"
 	"avito-parser/internal/models"
+	"avito-parser/internal/util"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
@@ -18,24 +24,282 @@ import (
 
 type AvitoParser struct {
 	browser   *rod.Browser
-	db        *database.RedisClient
+	db        database.Store
 	headless  bool
 	timeout   time.Duration
 	baseURL   string
 	cycleDelay time.Duration
 	pageDelay time.Duration
+	opts      Options
+	pending   []*models.Listing
+	proxies   *proxyPool
+	seen      *seenCache
+	blocks    *blockRate
+	failures  *saveFailures
+	// metrics is only touched by the parsing loop
+	metrics cycleMetrics
+	// details reads listing pages for the refresh and the watch list, the
+	// browser outside of tests
+	details detailFetcher
+	// prober and lister load search pages for the parsing cycle, the browser
+	// outside of tests
+	prober pageProber
+	lister pageLister
+
+	// browserMu guards the browser, which is replaced on relaunch and proxy switch
+	browserMu sync.Mutex
+	// cycleMu serializes runs that save listings, so concurrent callers
+	// don't interleave their writes and the Redis buffer
+	cycleMu sync.Mutex
 }
 
+// Options holds optional tuning parameters of the parser
+type Options struct {
+	// ElementWait bounds the total time spent waiting for a listing card's
+	// fields to render before giving up on it
+	ElementWait time.Duration
+	// ContentWait and ProbeWait cap the wait for search results to render
+	// when parsing and when checking a page for listings. ContentWait also
+	// caps the wait for a listing's own page when refreshing and for the
+	// cookie banner on warm-up.
+	ContentWait time.Duration
+	ProbeWait   time.Duration
+	// RetryDelay is the pause before a failed search page is tried again
+	RetryDelay time.Duration
+	// SelectorOverrides maps a selector set (listing, title, price, date,
+	// location, badge) to selectors tried before the built-in ones, to patch
+	// a single field quickly when Avito changes
+	SelectorOverrides map[string][]string
+	// SelectorTolerance is how many times a failing listing selector is
+	// retried before the page is reported as failed rather than empty
+	SelectorTolerance int
+
+	// IDStrategy decides which listings are the same record, ByAvitoID by default
+	IDStrategy models.IDStrategy
+
+	// MinTitleLength rejects listings with shorter titles
+	MinTitleLength int
+	// RejectTitles are labels that are never real titles
+	RejectTitles []string
+	// RequirePriceDigits rejects listings whose found price has no digits
+	RequirePriceDigits bool
+	// MinPrice and MaxPrice bound plausible prices, 0 disables a bound
+	MinPrice int64
+	MaxPrice int64
+	// SeenCacheSize is how many recently saved listings are remembered in
+	// memory to skip the existence check when saving them again, 0 disables it
+	SeenCacheSize int
+	// MaxStored caps the stored listings, evicting the first seen ones before
+	// new ones are saved, 0 disables it. Needs a store with sorted indexes.
+	MaxStored int
+	// FXRates convert foreign prices to PriceRUB, in rubles per unit of a currency code
+	FXRates map[string]float64
+
+	// BufferSize caps listings kept in memory while Redis is unavailable
+	BufferSize int
+	// SaveMaxAttempts is how many times a listing may fail to save for a
+	// reason other than the store being down before it is dead-lettered and
+	// skipped, 0 retries forever
+	SaveMaxAttempts int
+
+	// BlockKeywords are page texts that mean Avito blocked the visitor
+	BlockKeywords []string
+	// BodyScanLimit caps how many characters of page text are scanned, 0 scans all
+	BodyScanLimit int
+	// BlockAlertThreshold is the share of blocked page loads among the last
+	// BlockAlertWindow that raises an alert, 0 disables it. The cycle pauses
+	// for BlockAlertCooldown when the alert is raised.
+	BlockAlertThreshold float64
+	BlockAlertWindow    int
+	BlockAlertCooldown  time.Duration
+
+	// NoResultsSelector and NoResultsText identify Avito's empty-results state
+	NoResultsSelector string
+	NoResultsText     string
+
+	// SkipArchived doesn't store listings that are already removed from publication
+	SkipArchived bool
+
+	// RequireTags and ExcludeTags filter listings by their badges
+	RequireTags []string
+	ExcludeTags []string
+
+	// Locale sets browser locale and Accept-Language, e.g. ru-RU
+	Locale string
+	// Profile sets the user agent, language and screen of pages; its locale
+	// replaces Locale. Nil keeps the browser defaults.
+	Profile *BrowserProfile
+	// UserDataDir persists the browser profile between runs, temporary when empty
+	UserDataDir string
+	// BrowserLifetime is LifetimePersistent to reuse the browser across cycles
+	// or LifetimePerCycle to relaunch it for every cycle
+	BrowserLifetime string
+	// RevealPhone clicks the phone button on listing pages during -refresh
+	RevealPhone bool
+
+	// KeyNamespace prefixes the store keys that belong to this search rather
+	// than to a listing, so parsers of several cities can share one store.
+	// Listings keep their global ids.
+	KeyNamespace string
+	// CycleSlots is shared by parsers running side by side to bound how many
+	// of them parse at once; a cycle runs while holding a slot. Nil never waits.
+	CycleSlots chan struct{}
+
+	// CycleRetryBudget caps page retries across a whole cycle, 0 means no limit
+	CycleRetryBudget int
+	// CycleTimeout cancels a cycle once exceeded; listings parsed so far are
+	// still saved. 0 means no limit
+	CycleTimeout time.Duration
+
+	// MaxScrolls caps scrolling on pages without pagination, 0 disables it
+	MaxScrolls int
+	// MaxElements caps how many matched cards of a page are parsed, 0 disables it
+	MaxElements int
+	// SortByDate requests every search page sorted newest first
+	SortByDate bool
+
+	// MaxAge skips listings posted longer ago, 0 disables the filter
+	MaxAge time.Duration
+	// KeepUnknownAge keeps listings whose posting date couldn't be parsed
+	KeepUnknownAge bool
+
+	// NewWindow counts a listing as new while it was first seen within the
+	// window instead of only when it isn't stored, 0 keeps the latter. The
+	// first-seen time is kept apart from the listing so a lapsed TTL doesn't reset it.
+	NewWindow time.Duration
+
+	// Proxies are rotated on blocks, or on every page with ProxyRotateEachPage
+	Proxies []string
+	// ProxyMaxFailures takes a proxy out of rotation after that many failures, 0 never does
+	ProxyMaxFailures int
+	// ProxyRotateEachPage switches to the next proxy before every page
+	ProxyRotateEachPage bool
+
+	// IncludeLocations keeps only listings whose location contains one of
+	// them, ExcludeLocations drops those containing any; both ignore case
+	IncludeLocations []string
+	ExcludeLocations []string
+	// IncludeMetro keeps only listings whose metro station contains one of them, ignoring case
+	IncludeMetro []string
+	// KeepUnknownLocation keeps listings without a location or metro when location filters are set
+	KeepUnknownLocation bool
+
+	// RequireImages drops listings without any photo
+	RequireImages bool
+
+	// Location is the timezone of listing timestamps and Avito's relative dates
+	Location *time.Location
+	// NextDelay returns the pause between pages, the fixed page delay by default
+	NextDelay DelayFunc
+
+	// ImageDir receives the first photo of each saved listing as <id>.jpg,
+	// nothing is downloaded when empty
+	ImageDir string
+	// RepostWindow is how long the first photo of a listing is remembered to
+	// flag other ids with the same photo as possible reposts, 0 disables it
+	RepostWindow time.Duration
+
+	// Watchlist holds listing URLs or numeric Avito ids whose own pages are
+	// checked after every cycle, whether or not they appear in the search
+	Watchlist []string
+
+	// DryRun writes nothing besides the logs: no photos, snapshots or raw
+	// pages are saved and the seen cache stays empty
+	DryRun bool
+
+	// SnapshotDir receives a JSONL dump of all listings after each successful
+	// cycle, none are written when empty
+	SnapshotDir string
+	// SnapshotRetention is how long snapshots are kept, 0 keeps them forever
+	SnapshotRetention time.Duration
+	// CycleCommand is run after every cycle with its summary as JSON on
+	// stdin, the program first and its arguments after it; empty runs nothing
+	CycleCommand []string
+	// MetricsFile is rewritten after every cycle with the totals since start
+	// in Prometheus text format, none is written when empty
+	MetricsFile string
+
+	// StoreFields are the JSON names of the listing fields saved, besides its
+	// id, URL and bookkeeping; all fields are saved when empty
+	StoreFields []string
+
+	// RawHTMLDir receives the HTML of every loaded search page, none is kept
+	// when empty. Captures beyond RawHTMLMaxFiles or older than RawHTMLRetention
+	// are removed, 0 disables a limit.
+	RawHTMLDir       string
+	RawHTMLMaxFiles  int
+	RawHTMLRetention time.Duration
+
+	// QuietHours pauses continuous parsing every day within the window, nil never does
+	QuietHours *clock.Window
+	// Clock provides listing timestamps, the system clock in Location by default
+	Clock clock.Clock
+}
+
+// now returns the current time of the parser's clock
+func (p *AvitoParser) now() time.Time {
+	return p.opts.Clock.Now()
+}
+
+// Browser lifetime policies
+const (
+	LifetimePersistent = "persistent"
+	LifetimePerCycle   = "per-cycle"
+)
+
+// browserStartAttempts is how many times a per-cycle relaunch is tried
+const browserStartAttempts = 3
+
+// errSkipped is a save outcome that is not a failure
+var errSkipped = errors.New("listing skipped")
+
+// sortByDate is Avito's sort parameter value listing the newest ads first
+const sortByDate = "104"
+
+// listingTTL is how long a stored listing lives in Redis
+const listingTTL = 24 * time.Hour
+
+// elementRetryInterval is the pause between attempts to read a card field
+const elementRetryInterval = 100 * time.Millisecond
+
 // NewAvitoParser creates a new Avito parser instance
-func NewAvitoParser(db *database.RedisClient, headless bool, timeout time.Duration, baseURL string, cycleDelay, pageDelay time.Duration) *AvitoParser {
-	return &AvitoParser{
+func NewAvitoParser(db database.Store, headless bool, timeout time.Duration, baseURL string, cycleDelay, pageDelay time.Duration, opts Options) *AvitoParser {
+	if opts.IDStrategy == nil {
+		opts.IDStrategy = models.ByAvitoID{}
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real{Location: opts.Location}
+	}
+	if opts.Profile != nil && opts.Profile.Locale != "" {
+		opts.Locale = opts.Profile.Locale
+	}
+	if opts.NextDelay == nil {
+		opts.NextDelay = func() time.Duration { return pageDelay }
+	}
+	if opts.DryRun {
+		opts.ImageDir = ""
+		opts.SnapshotDir = ""
+		opts.RawHTMLDir = ""
+		opts.SeenCacheSize = 0
+	}
+
+	p := &AvitoParser{
 		db:        db,
 		headless:  headless,
 		timeout:   timeout,
 		baseURL:   baseURL,
 		cycleDelay: cycleDelay,
 		pageDelay: pageDelay,
+		opts:      opts,
+		proxies:   newProxyPool(opts.Proxies, opts.ProxyMaxFailures),
+		seen:      newSeenCache(opts.SeenCacheSize),
+		blocks:    newBlockRate(opts.BlockAlertWindow, opts.BlockAlertThreshold),
+		failures:  newSaveFailures(),
 	}
+	p.details = p.fetchDetail
+	p.prober = p.probePage
+	p.lister = p.ParseListings
+	return p
 }
 
 // Start initializes the browser
@@ -65,24 +329,123 @@ func (p *AvitoParser) Start() error {
 		Set("disable-backgrounding-occluded-windows").
 		Set("disable-renderer-backgrounding")
 
+	if p.opts.Locale != "" {
+		l = l.Set("lang", p.opts.Locale)
+	}
+
+	// Keep cookies and local storage across restarts when a profile dir is set
+	if p.opts.UserDataDir != "" {
+		err := os.MkdirAll(p.opts.UserDataDir, 0o755)
+		if err != nil {
+			return fmt.Errorf("failed to create user data dir: %w", err)
+		}
+		l = l.UserDataDir(p.opts.UserDataDir)
+		log.Printf("Using browser profile in %s", p.opts.UserDataDir)
+	} else {
+		log.Println("Using fresh temporary browser profile")
+	}
+
+	var proxyUser string
+	if p.proxies != nil {
+		var server string
+		server, proxyUser, _ = proxyServer(p.proxies.Current())
+		l = l.Proxy(server)
+		log.Printf("Using proxy %s", redactProxy(p.proxies.Current()))
+	}
+
 	url, err := l.Launch()
 	if err != nil {
 		return fmt.Errorf("failed to launch browser: %w", err)
 	}
 
-	p.browser = rod.New().ControlURL(url)
-	err = p.browser.Connect()
+	browser := rod.New().ControlURL(url)
+	err = browser.Connect()
 	if err != nil {
 		return fmt.Errorf("failed to connect to browser: %w", err)
 	}
 
+	// Chrome can't take proxy credentials as a flag, so answer the auth challenges
+	if proxyUser != "" {
+		err = handleProxyAuth(browser, p.proxies.Current())
+		if err != nil {
+			return err
+		}
+	}
+
+	p.browserMu.Lock()
+	p.browser = browser
+	p.browserMu.Unlock()
+
 	log.Println("Browser started successfully")
 	return nil
 }
 
-// generatePageURL generates URL for a specific page number
+// cityHomeURL returns the city homepage of the base URL, e.g. https://www.avito.ru/chelyabinsk
+func (p *AvitoParser) cityHomeURL() string {
+	parsedURL, err := url.Parse(p.baseURL)
+	if err != nil || parsedURL.Host == "" {
+		return "https://www.avito.ru"
+	}
+
+	homeURL := parsedURL.Scheme + "://" + parsedURL.Host
+	segments := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+	if len(segments) > 0 && segments[0] != "" {
+		homeURL += "/" + segments[0]
+	}
+	return homeURL
+}
+
+// Warmup opens the city homepage before scraping so the session gets cookies like a real visitor
+func (p *AvitoParser) Warmup(ctx context.Context) error {
+	homeURL := p.cityHomeURL()
+	log.Printf("Warming up session on %s", homeURL)
+
+	page, err := p.openPage(homeURL)
+	if err != nil {
+		return fmt.Errorf("failed to create page: %w", err)
+	}
+	defer page.Close()
+
+	err = page.WaitLoad()
+	if err != nil {
+		return fmt.Errorf("failed to wait for page load: %w", err)
+	}
+
+	// Let the page settle, the cookie banner is shown once it has
+	if err := waitForAny(ctx, page, consentSelectors, p.opts.ContentWait); err != nil {
+		return err
+	}
+
+	// Accept cookie consent if the banner is shown
+	for _, selector := range consentSelectors {
+		button, err := page.Sleeper(rod.NotFoundSleeper).Element(selector)
+		if err != nil || button == nil {
+			continue
+		}
+
+		err = button.Click(proto.InputMouseButtonLeft, 1)
+		if err != nil {
+			log.Printf("Failed to accept cookie consent: %v", err)
+		} else {
+			log.Println("Accepted cookie consent")
+		}
+		break
+	}
+
+	log.Println("Warm-up finished")
+	return nil
+}
+
+// consentSelectors match the accept button of the cookie consent banner
+var consentSelectors = []string{
+	"[data-marker*='cookie'] button",
+	"[data-marker*='consent'] button",
+	"button[data-marker*='cookie']",
+}
+
+// generatePageURL generates URL for a specific page number, sorted by date with SortByDate
 func (p *AvitoParser) generatePageURL(pageNum int) string {
-	if pageNum == 1 {
+	if pageNum == 1 && !p.opts.SortByDate {
 		return p.baseURL
 	}
 	
@@ -93,20 +456,53 @@ func (p *AvitoParser) generatePageURL(pageNum int) string {
 		return p.baseURL
 	}
 	
-	// Add page parameter
-	query := parsedURL.Query()
-	query.Set("p", fmt.Sprintf("%d", pageNum))
-	query.Set("localPriority", "0")
-	parsedURL.RawQuery = query.Encode()
+	// Merge the page and sort parameters, leaving the others as the user wrote them
+	query := parsedURL.RawQuery
+	if pageNum > 1 {
+		query = setQueryParam(query, "p", fmt.Sprintf("%d", pageNum), true)
+		query = setQueryParam(query, "localPriority", "0", false)
+	}
+	if p.opts.SortByDate {
+		query = setQueryParam(query, "s", sortByDate, true)
+	}
+	parsedURL.RawQuery = query
 	
 	return parsedURL.String()
 }
 
-// hasListings checks if page has listings (minimum threshold) with nil safety
-func (p *AvitoParser) hasListings(pageURL string) (bool, int, error) {
-	page, err := p.browser.Page(proto.TargetCreateTarget{URL: pageURL})
+// pageProbe describes what a quick look at a search page found
+type pageProbe struct {
+	// HasListings is set when the page has at least the minimum number of listings
+	HasListings bool
+	// Count is the number of listing elements found
+	Count int
+	// NoResults is set when Avito shows its explicit empty-results block
+	NoResults bool
+	// Selectors holds the match count of every selector when requested
+	Selectors []SelectorReport
+	// Total is the result count shown on the page when requested, 0 when unknown
+	Total int
+	// OutOfRange is set when the page number is past the last page, seen as a
+	// redirect to another page or as the empty-results block after page 1
+	OutOfRange bool
+	// RedirectedTo is the page Avito redirected to instead of the requested one
+	RedirectedTo string
+}
+
+// pageProber takes a quick look at a search page
+type pageProber func(pageURL string, countSelectors, readTotal bool) (*pageProbe, error)
+
+// pageLister parses the listings of a search page, returning those parsed so
+// far together with ctx's error when ctx is cancelled
+type pageLister func(ctx context.Context, pageURL string) ([]*models.Listing, error)
+
+// probePage checks if page has listings (minimum threshold) with nil safety.
+// With countSelectors set it also counts every selector for drift detection,
+// with readTotal it reads the total result count.
+func (p *AvitoParser) probePage(pageURL string, countSelectors, readTotal bool) (*pageProbe, error) {
+	page, err := p.openPage(pageURL)
 	if err != nil {
-		return false, 0, fmt.Errorf("failed to create page: %w", err)
+		return nil, fmt.Errorf("failed to create page: %w", err)
 	}
 	defer func() {
 		if page != nil {
@@ -117,30 +513,29 @@ func (p *AvitoParser) hasListings(pageURL string) (bool, int, error) {
 	// Wait for page to load
 	err = page.WaitLoad()
 	if err != nil {
-		return false, 0, fmt.Errorf("failed to wait for page load: %w", err)
+		return nil, fmt.Errorf("failed to wait for page load: %w", err)
 	}
 
-	// Wait a bit for dynamic content
-	time.Sleep(2 * time.Second)
+	// Wait for dynamic content
+	p.waitForContent(page, p.opts.ProbeWait)
 
-	// Try to find listings with multiple selectors
-	selectors := []string{
-		"[data-marker='item']",
-		"[data-marker*='item']",
-		".item, .listing-item",
+	if p.isBlocked(page) {
+		return nil, errBlocked
 	}
 
-	var listingElements rod.Elements
-	for _, selector := range selectors {
-		listingElements, err = page.Elements(selector)
-		if err == nil && len(listingElements) > 0 {
-			break
-		}
+	// A page past the last one redirects instead of failing, its listings
+	// belong to another page
+	probe := &pageProbe{}
+	if info, err := page.Info(); err == nil && redirectedPage(pageURL, info.URL) {
+		probe.OutOfRange = true
+		probe.RedirectedTo = info.URL
+		return probe, nil
 	}
-	
+
+	// Try to find listings with multiple selectors; a selector error is not an empty page
+	listingElements, _, err := p.findListingElements(page)
 	if err != nil {
-		log.Printf("Error finding listings on page: %v", err)
-		return false, 0, nil
+		return nil, err
 	}
 	
 	// Count valid (non-nil) elements
@@ -152,37 +547,107 @@ func (p *AvitoParser) hasListings(pageURL string) (bool, int, error) {
 	}
 	
 	log.Printf("Found %d valid listings on page", validCount)
-	return validCount >= 3, validCount, nil // Consider page valid if it has at least 3 listings
+	probe.HasListings = validCount >= 3 // Consider page valid if it has at least 3 listings
+	probe.Count = validCount
+	if validCount == 0 {
+		probe.NoResults = p.hasNoResultsMarker(page)
+		probe.OutOfRange = emptyPastLastPage(pageURL, probe.NoResults)
+	}
+	if countSelectors {
+		probe.Selectors, _ = p.selectorReports(page)
+	}
+	if readTotal {
+		probe.Total = readResultCount(page)
+	}
+	return probe, nil
 }
 
-// ParseAllPages parses all available pages starting from page 1 with improved error handling
-func (p *AvitoParser) ParseAllPages() error {
+// hasNoResultsMarker checks for Avito's explicit "nothing found" block on the page
+func (p *AvitoParser) hasNoResultsMarker(page *rod.Page) bool {
+	if p.opts.NoResultsSelector != "" {
+		marker, err := page.Sleeper(rod.NotFoundSleeper).Element(p.opts.NoResultsSelector)
+		if err == nil && marker != nil {
+			return true
+		}
+	}
+
+	if p.opts.NoResultsText == "" {
+		return false
+	}
+
+	body, err := page.Element("body")
+	if err != nil || body == nil {
+		return false
+	}
+
+	bodyText, err := body.Text()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(bodyText), strings.ToLower(p.opts.NoResultsText))
+}
+
+
+
+// ParseAllPages parses all available pages starting from page 1 with improved error handling.
+// The cycle stops between pages once ctx is cancelled. The result holds what
+// was processed so far even when an error is returned.
+func (p *AvitoParser) ParseAllPages(ctx context.Context) (result *CycleResult, err error) {
+	p.cycleMu.Lock()
+	defer p.cycleMu.Unlock()
+
 	log.Println("Starting full parsing cycle...")
 	
-	totalNewListings := 0
-	totalPages := 0
+	result = &CycleResult{}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	// Compare the first page with the selector baseline when one is recorded
+	baseline := p.loadBaseline(ctx)
+
 	currentPage := 1
+	maxPages := fallbackMaxPages
 	maxRetries := 3
+	budget := newRetryBudget(p.opts.CycleRetryBudget)
 	
 	for {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Parsing cycle cancelled before page %d: %d pages processed, %d new listings saved", currentPage, result.Pages, result.New)
+			return result, err
+		}
+
+		if p.opts.ProxyRotateEachPage && result.Pages > 0 {
+			p.rotateProxy(false)
+		}
+
 		pageURL := p.generatePageURL(currentPage)
 		log.Printf("Processing page %d...", currentPage)
 		
 		// Check if page has enough listings with retry
-		var hasListings bool
-		var listingCount int
-		var err error
+		var probe *pageProbe
 		
 		for retry := 0; retry < maxRetries; retry++ {
-			hasListings, listingCount, err = p.hasListings(pageURL)
-			if err == nil {
+			probe, err = p.prober(pageURL, baseline != nil && currentPage == 1, currentPage == 1)
+			p.recordPageLoad(ctx, err)
+			if err == nil || retry == maxRetries-1 || !budget.take() {
 				break
 			}
+			if errors.Is(err, errBlocked) {
+				result.Blocked++
+				p.rotateProxy(true)
+			}
 			log.Printf("Retry %d for page %d: %v", retry+1, currentPage, err)
-			time.Sleep(2 * time.Second)
+			if sleep(ctx, p.opts.RetryDelay) != nil {
+				break
+			}
 		}
 		
 		if err != nil {
+			if budget.exhausted() {
+				return result, &RetryBudgetError{Budget: p.opts.CycleRetryBudget, Page: currentPage, Err: err}
+			}
 			log.Printf("Failed to check page %d after %d retries: %v, skipping...", currentPage, maxRetries, err)
 			currentPage++
 			if currentPage > 10 { // Safety limit
@@ -191,91 +656,335 @@ func (p *AvitoParser) ParseAllPages() error {
 			continue
 		}
 		
-		if !hasListings {
-			log.Printf("Found %d listings on page %d (less than minimum 3), ending pagination", listingCount, currentPage)
+		if probe.Selectors != nil {
+			if drift, details := detectDrift(baseline, probe.Selectors); drift {
+				log.Printf("⚠️  WARNING: DOM drift suspected, Avito markup may have changed: %s", details)
+			}
+		}
+
+		if !probe.HasListings {
+			log.Print(paginationEnd(currentPage, probe))
 			break
 		}
+
+		// Bound pagination by the result count shown on the first page
+		if currentPage == 1 {
+			maxPages = pageLimit(probe.Total, probe.Count)
+			if probe.Total > 0 {
+				log.Printf("Search shows %d results, expecting %d pages", probe.Total, maxPages)
+			}
+		}
 		
 		// Parse the page with retry
 		var listings []*models.Listing
 		for retry := 0; retry < maxRetries; retry++ {
-			listings, err = p.ParseListings(pageURL)
-			if err == nil {
+			listings, err = p.lister(ctx, pageURL)
+			p.recordPageLoad(ctx, err)
+			if err == nil || ctx.Err() != nil || retry == maxRetries-1 || !budget.take() {
 				break
 			}
+			if errors.Is(err, errBlocked) {
+				result.Blocked++
+				p.rotateProxy(true)
+			}
 			log.Printf("Retry %d parsing page %d: %v", retry+1, currentPage, err)
-			time.Sleep(2 * time.Second)
+			if sleep(ctx, p.opts.RetryDelay) != nil {
+				break
+			}
 		}
 		
-		if err != nil {
+		for _, listing := range listings {
+			if listing != nil {
+				listing.SourcePage = currentPage
+			}
+		}
+
+		// A cancelled parse still saves what it got; the cycle stops at the next page
+		if err != nil && ctx.Err() != nil {
+			log.Printf("Parsing of page %d interrupted, saving %d listings parsed so far", currentPage, len(listings))
+		} else if err != nil {
+			if budget.exhausted() {
+				return result, &RetryBudgetError{Budget: p.opts.CycleRetryBudget, Page: currentPage, Err: err}
+			}
 			log.Printf("Failed to parse page %d after %d retries: %v, skipping...", currentPage, maxRetries, err)
 			currentPage++
 			continue
 		}
 		
-		// Save listings
-		newListingsCount := 0
+		// Save listings, flushing anything buffered while Redis was down first.
+		// Writes outlive cancellation so an interrupted page is still saved;
+		// the per-call Redis timeout keeps them from blocking shutdown.
+		saveCtx := context.WithoutCancel(ctx)
+		newListingsCount := p.flushPending(saveCtx)
+		filteredCount := 0
+		ageFilteredCount := 0
+		locationFilteredCount := 0
+		noPhotosCount := 0
+		toSave := make([]*models.Listing, 0, len(listings))
 		for _, listing := range listings {
 			if listing == nil {
 				continue // Skip nil listings
 			}
 			
-			err := p.SaveListing(listing)
-			if err != nil {
-				if !strings.Contains(err.Error(), "already exists") {
-					log.Printf("Error saving listing: %v", err)
-				}
-			} else {
-				newListingsCount++
+			if reason := p.filterByAge(listing, p.now()); reason != "" {
+				log.Printf("Filtered listing %s: %s", listing.ID, reason)
+				p.refreshTTL(saveCtx, listing)
+				ageFilteredCount++
+				continue
+			}
+
+			reason := p.filterByLocation(listing)
+			if reason == "" {
+				reason = p.filterByMetro(listing)
+			}
+			if reason != "" {
+				log.Printf("Filtered listing %s: %s", listing.ID, reason)
+				p.refreshTTL(saveCtx, listing)
+				locationFilteredCount++
+				continue
+			}
+
+			if reason := p.filterByImages(listing); reason != "" {
+				log.Printf("Filtered listing %s: %s", listing.ID, reason)
+				p.refreshTTL(saveCtx, listing)
+				noPhotosCount++
+				continue
+			}
+
+			if reason := p.filterListing(listing); reason != "" {
+				log.Printf("Filtered listing %s: %s", listing.ID, reason)
+				p.refreshTTL(saveCtx, listing)
+				filteredCount++
+				continue
 			}
+
+			toSave = append(toSave, listing)
+		}
+
+		p.downloadImages(ctx, toSave)
+		p.flagReposts(saveCtx, toSave)
+
+		counts, err := p.saveBatchOrBuffer(saveCtx, toSave)
+		if err != nil && !errors.Is(err, errBuffered) {
+			log.Printf("Error saving listings: %v", err)
+		}
+		pageResult := PageResult{
+			Page:      currentPage,
+			URL:       pageURL,
+			Found:     len(listings),
+			New:       newListingsCount + counts.New,
+			Updated:   counts.Updated,
+			Filtered:  filteredCount,
+			TooOld:    ageFilteredCount,
+			OutOfArea: locationFilteredCount,
+			NoPhotos:  noPhotosCount,
 		}
 		
-		log.Printf("Found %d listings on page %d, saved %d new listings", len(listings), currentPage, newListingsCount)
-		totalNewListings += newListingsCount
-		totalPages++
+		log.Printf("Found %d listings on page %d, saved %d new listings, updated %d, filtered %d, too old %d, out of area %d, no photos %d", pageResult.Found, currentPage, pageResult.New, pageResult.Updated, pageResult.Filtered, pageResult.TooOld, pageResult.OutOfArea, pageResult.NoPhotos)
+		result.addPage(pageResult)
 		
 		// Delay before next page
-		if p.pageDelay > 0 {
-			time.Sleep(p.pageDelay)
+		if delay := p.opts.NextDelay(); delay > 0 {
+			sleep(ctx, delay)
 		}
 		
 		currentPage++
 		
 		// Safety limit to prevent infinite loops
-		if currentPage > 50 {
-			log.Println("Reached maximum page limit (50), ending pagination")
+		if currentPage > maxPages {
+			log.Printf("Reached page limit (%d), ending pagination", maxPages)
 			break
 		}
 	}
 	
-	log.Printf("Total cycle results: %d pages processed, %d new listings saved", totalPages, totalNewListings)
-	return nil
+	log.Printf("Total cycle results: %d pages processed, %d new listings saved, %d updated in %v", result.Pages, result.New, result.Updated, time.Since(start).Round(time.Second))
+	return result, nil
 }
 
-// StartContinuousParsing starts continuous parsing with cycles
-func (p *AvitoParser) StartContinuousParsing() {
+// cycleContext bounds a cycle by CycleTimeout when one is set
+func (p *AvitoParser) cycleContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.opts.CycleTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, p.opts.CycleTimeout)
+}
+
+// StartContinuousParsing starts continuous parsing with cycles until ctx is cancelled
+func (p *AvitoParser) StartContinuousParsing(ctx context.Context) {
 	for {
+		if err := p.waitQuietHours(ctx); err != nil {
+			log.Println("Continuous parsing stopped")
+			return
+		}
+
+		// The browser is closed after each cycle with the per-cycle lifetime
+		if _, err := p.currentBrowser(); err != nil {
+			if err := p.relaunch(ctx); err != nil {
+				log.Printf("Continuous parsing stopped: %v", err)
+				return
+			}
+		}
+
+		if err := p.acquireCycleSlot(ctx); err != nil {
+			log.Println("Continuous parsing stopped")
+			return
+		}
+
 		func() {
+			defer p.releaseCycleSlot()
 			defer func() {
 				if r := recover(); r != nil {
 					log.Printf("Recovered from panic in parsing cycle: %v", r)
 				}
 			}()
 			
-			err := p.ParseAllPages()
-			if err != nil {
+			cycleCtx, cancel := p.cycleContext(ctx)
+			defer cancel()
+
+			result, err := p.ParseAllPages(cycleCtx)
+			switch {
+			case err == nil || ctx.Err() != nil:
+			case errors.Is(cycleCtx.Err(), context.DeadlineExceeded):
+				log.Printf("Parsing cycle timed out after %v: %d pages processed, %d new listings saved, %d updated", p.opts.CycleTimeout, result.Pages, result.New, result.Updated)
+			default:
 				log.Printf("Error during parsing cycle: %v", err)
 			}
+			if err == nil {
+				p.snapshot()
+			}
+			p.runCycleCommand(ctx, result, err)
+			p.writeMetrics(result, err)
+			if ctx.Err() == nil {
+				p.CheckWatchlist(ctx)
+			}
 		}()
 		
+		if ctx.Err() != nil {
+			log.Println("Continuous parsing stopped")
+			return
+		}
+
+		if p.opts.BrowserLifetime == LifetimePerCycle {
+			log.Println("Closing browser until the next cycle")
+			if err := p.Close(); err != nil {
+				log.Printf("Error closing browser: %v", err)
+			}
+		}
+
 		log.Printf("Waiting %v before next cycle...", p.cycleDelay)
-		time.Sleep(p.cycleDelay)
+		if err := sleep(ctx, p.cycleDelay); err != nil {
+			log.Println("Continuous parsing stopped")
+			return
+		}
 	}
 }
 
-// ParseListings parses apartment listings from the given URL with nil safety
-func (p *AvitoParser) ParseListings(url string) ([]*models.Listing, error) {
-	page, err := p.browser.Page(proto.TargetCreateTarget{URL: url})
+// snapshot dumps the stored listings to SnapshotDir and prunes old snapshots.
+// Failures are only logged so they never stop the parsing loop.
+func (p *AvitoParser) snapshot() {
+	if p.opts.SnapshotDir == "" {
+		return
+	}
+
+	now := p.now()
+	path, err := export.Snapshot(p.db, p.opts.SnapshotDir, now)
+	if err != nil {
+		log.Printf("Failed to write snapshot: %v", err)
+		return
+	}
+	log.Printf("Wrote snapshot %s", path)
+
+	if p.opts.SnapshotRetention <= 0 {
+		return
+	}
+	removed, err := export.PruneSnapshots(p.opts.SnapshotDir, p.opts.SnapshotRetention, now)
+	if err != nil {
+		log.Printf("Failed to prune snapshots: %v", err)
+	}
+	if removed > 0 {
+		log.Printf("Pruned %d snapshots older than %v", removed, p.opts.SnapshotRetention)
+	}
+}
+
+// relaunch starts the browser again, retrying a few times before giving up
+func (p *AvitoParser) relaunch(ctx context.Context) error {
+	var err error
+	for attempt := 1; attempt <= browserStartAttempts; attempt++ {
+		err = p.Start()
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("Failed to relaunch browser (attempt %d of %d): %v", attempt, browserStartAttempts, err)
+		if sleepErr := sleep(ctx, time.Duration(attempt)*5*time.Second); sleepErr != nil {
+			return sleepErr
+		}
+	}
+	return fmt.Errorf("failed to relaunch browser after %d attempts: %w", browserStartAttempts, err)
+}
+
+// acquireCycleSlot waits for a free cycle slot when slots are shared
+func (p *AvitoParser) acquireCycleSlot(ctx context.Context) error {
+	if p.opts.CycleSlots == nil {
+		return nil
+	}
+
+	select {
+	case p.opts.CycleSlots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releaseCycleSlot frees the slot taken by acquireCycleSlot
+func (p *AvitoParser) releaseCycleSlot() {
+	if p.opts.CycleSlots != nil {
+		<-p.opts.CycleSlots
+	}
+}
+
+// namespacedKey returns the store key of this search, prefixed with KeyNamespace when set
+func (p *AvitoParser) namespacedKey(key string) string {
+	if p.opts.KeyNamespace == "" {
+		return key
+	}
+	return p.opts.KeyNamespace + ":" + key
+}
+
+// waitQuietHours sleeps until the quiet hours end if they are on now
+func (p *AvitoParser) waitQuietHours(ctx context.Context) error {
+	if p.opts.QuietHours == nil || !p.opts.QuietHours.Contains(p.now()) {
+		return nil
+	}
+
+	wait := p.opts.QuietHours.Remaining(p.now())
+	log.Printf("Entering quiet hours %v, pausing for %v", p.opts.QuietHours, wait.Round(time.Minute))
+	if err := sleep(ctx, wait); err != nil {
+		return err
+	}
+	log.Println("Leaving quiet hours, resuming parsing")
+	return nil
+}
+
+// sleep pauses for the given duration or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ParseListings parses apartment listings from the given URL with nil safety.
+// If ctx is cancelled while cards are being parsed, the listings parsed so far
+// are returned together with ctx's error so the caller can still save them.
+func (p *AvitoParser) ParseListings(ctx context.Context, url string) ([]*models.Listing, error) {
+	page, err := p.openPage(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create page: %w", err)
 	}
@@ -291,45 +1000,69 @@ func (p *AvitoParser) ParseListings(url string) ([]*models.Listing, error) {
 		return nil, fmt.Errorf("failed to wait for page load: %w", err)
 	}
 
-	// Wait a bit more for dynamic content
-	time.Sleep(3 * time.Second)
+	// Wait for dynamic content
+	p.waitForContent(page, p.opts.ContentWait)
 
-	// Try multiple selectors to find listings
-	selectors := []string{
-		"[data-marker='item']",
-		"[data-marker*='item']",
-		".item, .listing-item",
+	p.saveRawHTML(page, url)
+
+	if p.isBlocked(page) {
+		return nil, errBlocked
 	}
 
-	var listingElements rod.Elements
-	for _, selector := range selectors {
-		listingElements, err = page.Elements(selector)
-		if err == nil && len(listingElements) > 0 {
-			log.Printf("Found %d elements with selector: %s", len(listingElements), selector)
-			break
-		}
+	// Try multiple selectors to find listings
+	listingElements, matchedSelector, err := p.findListingElements(page)
+	if err != nil {
+		return nil, err
 	}
 
-	if err != nil || len(listingElements) == 0 {
+	if len(listingElements) == 0 {
 		log.Printf("No listing elements found on page")
 		return []*models.Listing{}, nil
 	}
+	log.Printf("Found %d elements with selector: %s", len(listingElements), matchedSelector)
 
+	// Some layouts load more items on scroll instead of numbered pages
+	listingElements = p.loadByScrolling(page, matchedSelector, listingElements)
+
+	// A selector matching far more than a page of cards has gone wrong
+	if p.opts.MaxElements > 0 && len(listingElements) > p.opts.MaxElements {
+		log.Printf("⚠️  WARNING: selector %s matched %d elements, parsing only the first %d", matchedSelector, len(listingElements), p.opts.MaxElements)
+		listingElements = listingElements[:p.opts.MaxElements]
+	}
+
+	return p.parseElements(ctx, listingElements)
+}
+
+// parseElements extracts listings from card elements, skipping cards that fail
+// or are rejected. On cancellation it stops and returns what it has with ctx's error.
+func (p *AvitoParser) parseElements(ctx context.Context, listingElements rod.Elements) ([]*models.Listing, error) {
 	var listings []*models.Listing
 
 	for i, element := range listingElements {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Parsing interrupted after %d of %d elements", i, len(listingElements))
+			return listings, err
+		}
+
 		if element == nil {
 			log.Printf("Skipping nil element at index %d", i)
 			continue
 		}
 		
 		listing, err := p.parseListingElement(element)
+		var rejected *RejectedError
+		if errors.As(err, &rejected) {
+			log.Printf("Rejected listing %d: %s", i, rejected.Reason)
+			continue
+		}
 		if err != nil {
 			log.Printf("Failed to parse listing %d: %v", i, err)
 			continue
 		}
 
 		if listing != nil {
+			// Position is where the card was on the page, skipped cards included
+			listing.Position = i + 1
 			listings = append(listings, listing)
 		}
 	}
@@ -344,128 +1077,349 @@ func (p *AvitoParser) parseListingElement(element *rod.Element) (*models.Listing
 		return nil, fmt.Errorf("element is nil")
 	}
 
+	// All fields of a card share one wait budget so large pages stay fast
+	deadline := time.Now().Add(p.opts.ElementWait)
+
 	// Extract title with multiple selectors and nil checks
-	titleSelectors := []string{
-		"[itemprop='name']",
-		"[data-marker='item-title'] a",
-		"h3 a",
-		".item-title a",
-		"[data-marker*='title'] a",
-		"a[title]",
-	}
-
-	var title string
-	for _, selector := range titleSelectors {
-		titleElement, err := element.Element(selector)
-		if err == nil && titleElement != nil {
-			title, err = titleElement.Text()
-			if err == nil && strings.TrimSpace(title) != "" {
-				title = strings.TrimSpace(title)
-				break
-			}
-		}
-	}
+	title := p.extractText(element, p.selectorsFor("title", titleSelectors), deadline)
 
 	if title == "" {
 		return nil, fmt.Errorf("title not found or empty")
 	}
 
 	// Extract price with multiple selectors and nil checks
-	priceSelectors := []string{
-		"[itemprop='price']",
-		"[data-marker='item-price']",
-		".price",
-		"[data-marker*='price']",
-		".item-price",
-	}
-
-	var price string = "Price not specified"
-	for _, selector := range priceSelectors {
-		priceElement, err := element.Element(selector)
-		if err == nil && priceElement != nil {
-			priceText, err := priceElement.Text()
-			if err == nil && strings.TrimSpace(priceText) != "" {
-				price = strings.TrimSpace(priceText)
-				break
-			}
-		}
+	price := p.extractText(element, p.selectorsFor("price", priceSelectors), deadline)
+	if price == "" {
+		price = priceNotSpecified
 	}
 
+	// Extract publication date to detect re-promoted listings
+	now := p.now()
+	dateText := p.extractOptionalText(element, p.selectorsFor("date", dateSelectors))
+	bumped, bumpedAt := parseBumped(dateText, now)
+
+	// A bumped card shows the re-publication date, not the original one
+	var postedAt time.Time
+	if !bumped {
+		postedAt, _ = parseRelativeDate(dateText, now)
+	}
+
+	// Extract address or district for location filters
+	location := p.extractOptionalText(element, p.selectorsFor("location", locationSelectors))
+	metro, metroMinutes := parseMetro(p.extractOptionalText(element, p.selectorsFor("metro", metroSelectors)))
+
+	// Detect listings removed from publication
+	status := models.StatusActive
+	cardText, err := element.Text()
+	if err == nil && isArchived(cardText) {
+		status = models.StatusArchived
+	}
+
+	// Extract photo URLs
+	images := extractImages(element)
+
+	// Extract badges like "Собственник" or "Проверено"
+	tags := p.extractTags(element)
+	newBadge, priceCutBadge := badgeSignals(tags)
+
 	// Extract URL with nil checks
 	var itemURL string
 	linkElement, err := element.Element("a[href]")
 	if err == nil && linkElement != nil {
 		href, err := linkElement.Attribute("href")
 		if err == nil && href != nil && *href != "" {
-			itemURL = *href
-			if !strings.HasPrefix(itemURL, "http") {
-				itemURL = "https://www.avito.ru" + itemURL
+			itemURL = absoluteURL(*href)
+		}
+	}
+
+	listing := &models.Listing{
+		Title:      title,
+		Price:      price,
+		URL:        itemURL,
+		Location:   location,
+		Images:     images,
+		PostedAt:   postedAt,
+		Bumped:     bumped,
+		BumpedAt:   bumpedAt,
+		Status:     status,
+		Tags:       tags,
+
+		AvitoNewBadge:      newBadge,
+		AvitoPriceCutBadge: priceCutBadge,
+
+		Metro:        metro,
+		MetroMinutes: metroMinutes,
+
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		LastSeenAt: now,
+
+		ObservedCount: 1,
+	}
+
+	// Drop listings that look like extraction garbage
+	err = p.checkQuality(listing)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bounds and the price per meter are in rubles
+	listing.Currency = models.DetectCurrency(listing.Price)
+	listing.PriceRUB = listing.ComputePriceRUB(p.opts.FXRates)
+	p.checkPriceBounds(listing)
+	listing.PricePerSqm = listing.ComputePricePerSqm()
+
+	// Generate ID with the configured dedup strategy
+	listing.ID = p.opts.IDStrategy.ID(listing)
+	listing.Signature = listing.Fingerprint()
+
+	return listing, nil
+}
+
+// extractTags collects the normalized, de-duplicated badge texts of a card
+func (p *AvitoParser) extractTags(element *rod.Element) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, selector := range p.selectorsFor("badge", badgeSelectors) {
+		badges, err := element.Elements(selector)
+		if err != nil {
+			continue
+		}
+
+		for _, badge := range badges {
+			text, err := badge.Text()
+			if err != nil {
+				continue
 			}
+
+			tag := util.NormalizeText(text)
+			key := strings.ToLower(tag)
+			if tag == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			tags = append(tags, tag)
 		}
 	}
 
-	// Generate unique ID based on URL or title
-	id := fmt.Sprintf("listing_%d", time.Now().UnixNano())
-	if itemURL != "" {
-		id = fmt.Sprintf("listing_%s", strings.ReplaceAll(itemURL, "/", "_"))
+	return tags
+}
+
+// extractText returns the normalized text of the first selector match with non-empty text.
+// Cards may render asynchronously, so lookups are retried until the deadline passes.
+func (p *AvitoParser) extractText(element *rod.Element, selectors []string, deadline time.Time) string {
+	for {
+		for _, selector := range selectors {
+			// Don't let rod wait indefinitely for a selector that is absent
+			found, err := element.Sleeper(rod.NotFoundSleeper).Element(selector)
+			if err != nil || found == nil {
+				continue
+			}
+
+			text, err := found.Text()
+			if err == nil && util.NormalizeText(text) != "" {
+				return fullText(found, util.NormalizeText(text))
+			}
+		}
+
+		if time.Now().Add(elementRetryInterval).After(deadline) {
+			return ""
+		}
+		time.Sleep(elementRetryInterval)
+	}
+}
+
+// extractOptionalText is extractText for fields many cards lack, like the
+// metro station. It looks once without waiting, since the title and price
+// were already awaited and the card has rendered.
+func (p *AvitoParser) extractOptionalText(element *rod.Element, selectors []string) string {
+	for _, selector := range selectors {
+		found, err := element.Elements(selector)
+		if err != nil || len(found) == 0 {
+			continue
+		}
+
+		text, err := found[0].Text()
+		if err == nil && util.NormalizeText(text) != "" {
+			return fullText(found[0], util.NormalizeText(text))
+		}
+	}
+	return ""
+}
+
+// SaveListing upserts a listing into storage. An already stored record keeps
+// its first-seen CreatedAt while the other fields are replaced with the fresh
+// observation. It reports whether the listing counts as new. Storage calls
+// are cancelled together with ctx.
+func (p *AvitoParser) SaveListing(ctx context.Context, listing *models.Listing) (bool, error) {
+	if listing != nil && listing.ID == "" {
+		listing.ID = p.opts.IDStrategy.ID(listing)
+	}
+	if err := listing.Validate(); err != nil {
+		return false, err
+	}
+	if p.isDeadLettered(listing.ID) {
+		return false, errSkipped
+	}
+
+	// Recently saved listings are known without asking the store
+	db := p.dbFor(ctx)
+	stored, cached := p.seen.Get(listing.ID, p.now())
+	if !cached {
+		var err error
+		stored, err = loadListing(db, listing.ID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if stored == nil && listing.Status == models.StatusArchived && p.opts.SkipArchived {
+		return false, errSkipped
+	}
+
+	now := p.now()
+	if listing.CreatedAt.IsZero() {
+		listing.CreatedAt = now
+	}
+	listing.ObservedCount = 1
+	if stored != nil {
+		listing.CreatedAt = stored.CreatedAt
+		listing.ObservedCount = observedCount(stored) + 1
+		listing.Tracked = stored.Tracked
+		if listing.Status == models.StatusArchived && stored.Status != models.StatusArchived {
+			log.Printf("Listing archived: %s - %s", listing.Title, listing.Price)
+		}
 	} else {
-		// Use title hash as fallback
-		id = fmt.Sprintf("listing_title_%d", len(title))
+		listing.CreatedAt = p.loadFirstSeen(db, listing.ID, listing.CreatedAt)
 	}
+	listing.UpdatedAt = now
+	listing.LastSeenAt = now
 
-	listing := &models.Listing{
-		ID:        id,
-		Title:     title,
-		Price:     price,
-		URL:       itemURL,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+	// Convert to JSON, keeping only the stored fields
+	saved := p.project(listing)
+	data, err := saved.ToJSON()
+	if err != nil {
+		p.recordSaveFailure(db, listing, err)
+		return false, fmt.Errorf("failed to convert listing to JSON: %w", err)
 	}
 
-	return listing, nil
+	if stored == nil {
+		p.makeRoom(db, 1)
+	}
+
+	// Save to Redis with 24 hour expiration
+	err = db.Set(listing.ID, string(data), listingTTL)
+	if err != nil {
+		p.recordSaveFailure(db, listing, err)
+		return false, fmt.Errorf("failed to save listing to Redis: %w", err)
+	}
+	p.failures.Clear(listing.ID)
+	p.seen.Add(saved, now.Add(listingTTL))
+	p.indexStored(db, []*models.Listing{listing})
+
+	if change := models.NewListingChange(stored, saved); change != nil {
+		log.Printf("Listing %s changed: %s", listing.ID, change)
+	}
+
+	p.recordFirstSeen(db, listing)
+
+	isNew := isNewListing(stored != nil, listing.CreatedAt, now, p.opts.NewWindow)
+	indexFingerprint(db, listing, isNew)
+
+	// Don't log for existing listings to reduce noise
+	if isNew {
+		log.Printf("Saved listing: %s - %s", listing.Title, listing.Price)
+	}
+	return isNew, nil
 }
 
-// SaveListing saves a listing to Redis with improved error handling
-func (p *AvitoParser) SaveListing(listing *models.Listing) error {
-	if listing == nil {
-		return fmt.Errorf("listing is nil")
+// indexFingerprint records the listing under its fingerprint so the same
+// apartment can be found under other ids. Duplicates are reported for new listings only.
+func indexFingerprint(db database.Store, listing *models.Listing, isNew bool) {
+	indexer, ok := db.(database.Indexer)
+	if !ok || listing.Signature == "" {
+		return
+	}
+
+	index := database.FingerprintIndex(listing.Signature)
+	err := indexer.AddToIndex(index, listing.ID, listingTTL)
+	if err != nil {
+		log.Printf("Failed to index fingerprint of %s: %v", listing.ID, err)
+		return
 	}
 
-	if listing.ID == "" {
-		return fmt.Errorf("listing ID is empty")
+	if !isNew {
+		return
 	}
 
-	// Check if listing already exists
-	exists, err := p.db.Exists(listing.ID)
+	members, err := indexer.IndexMembers(index)
+	if err == nil && len(members) > 1 {
+		log.Printf("Possible duplicate listings with fingerprint %s: %v", listing.Signature, members)
+	}
+}
+
+// observedCount is how many times the stored listing was seen; records saved
+// before observations were counted have been seen at least once
+func observedCount(stored *models.Listing) int {
+	if stored.ObservedCount < 1 {
+		return 1
+	}
+	return stored.ObservedCount
+}
+
+// project is what of the listing gets saved, only the StoreFields when they are set
+func (p *AvitoParser) project(listing *models.Listing) *models.Listing {
+	return listing.Project(p.opts.StoreFields)
+}
+
+// loadListing reads a stored listing, returning nil if it isn't stored
+func loadListing(db database.Store, id string) (*models.Listing, error) {
+	exists, err := db.Exists(id)
 	if err != nil {
-		return fmt.Errorf("failed to check if listing exists: %w", err)
+		return nil, fmt.Errorf("failed to check if listing exists: %w", err)
 	}
 
-	if exists {
-		// Don't log for existing listings to reduce noise
-		return fmt.Errorf("listing already exists")
+	if !exists {
+		return nil, nil
 	}
 
-	// Convert to JSON
-	data, err := listing.ToJSON()
+	data, err := db.Get(id)
 	if err != nil {
-		return fmt.Errorf("failed to convert listing to JSON: %w", err)
+		return nil, fmt.Errorf("failed to load listing: %w", err)
 	}
 
-	// Save to Redis with 24 hour expiration
-	err = p.db.Set(listing.ID, string(data), 24*time.Hour)
+	stored, err := models.FromJSON([]byte(data))
 	if err != nil {
-		return fmt.Errorf("failed to save listing to Redis: %w", err)
+		return nil, fmt.Errorf("failed to parse stored listing: %w", err)
 	}
+	return stored, nil
+}
 
-	log.Printf("Saved listing: %s - %s", listing.Title, listing.Price)
-	return nil
+// dbFor returns the store with its calls cancelled together with ctx
+func (p *AvitoParser) dbFor(ctx context.Context) database.Store {
+	return database.WithContext(ctx, p.db)
+}
+
+// currentBrowser returns the running browser, or an error while there is none
+func (p *AvitoParser) currentBrowser() (*rod.Browser, error) {
+	p.browserMu.Lock()
+	defer p.browserMu.Unlock()
+
+	if p.browser == nil {
+		return nil, errors.New("browser is not running")
+	}
+	return p.browser, nil
 }
 
 // Close closes the browser
 func (p *AvitoParser) Close() error {
-	if p.browser != nil {
-		return p.browser.Close()
+	p.browserMu.Lock()
+	browser := p.browser
+	p.browser = nil
+	p.browserMu.Unlock()
+
+	if browser != nil {
+		return browser.Close()
 	}
 	return nil
 }
\ No newline at end of file
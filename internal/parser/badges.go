@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"strings"
+)
+
+// newBadgeTexts are the lowercase texts of Avito's badge for fresh listings
+var newBadgeTexts = []string{"новое", "новое объявление", "new"}
+
+// badgeSignals reads Avito's own "new" and "price reduced" badges from the
+// card's tags. They are independent of the parser's change detection.
+func badgeSignals(tags []string) (isNew, priceCut bool) {
+	for _, tag := range tags {
+		text := strings.ToLower(tag)
+		for _, badge := range newBadgeTexts {
+			if text == badge {
+				isNew = true
+			}
+		}
+		// "Цена снижена", "Снизили цену", "Price reduced"
+		if (strings.Contains(text, "сниж") || strings.Contains(text, "сниз")) && strings.Contains(text, "цен") ||
+			strings.Contains(text, "price reduced") || strings.Contains(text, "price drop") {
+			priceCut = true
+		}
+	}
+	return isNew, priceCut
+}
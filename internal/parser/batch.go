@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// saveCounts is the outcome of saving a batch of listings
+type saveCounts struct {
+	New     int
+	Updated int
+	Skipped int
+}
+
+// SaveListings upserts a batch of listings. Stores that support batches get
+// one round trip to read existing records and one pipeline to write all of
+// them. It returns how many listings were new; failures of individual
+// listings are joined into the returned error.
+func (p *AvitoParser) SaveListings(ctx context.Context, listings []*models.Listing) (int, error) {
+	counts, err := p.saveBatch(ctx, listings)
+	return counts.New, err
+}
+
+// saveBatch upserts listings and counts new, updated and skipped ones
+func (p *AvitoParser) saveBatch(ctx context.Context, listings []*models.Listing) (saveCounts, error) {
+	var counts saveCounts
+
+	db := p.dbFor(ctx)
+	batch, ok := db.(database.BatchStore)
+	if !ok {
+		var errs []error
+		for _, listing := range listings {
+			created, err := p.SaveListing(ctx, listing)
+			switch {
+			case errors.Is(err, errSkipped):
+				counts.Skipped++
+			case err != nil:
+				errs = append(errs, err)
+			case created:
+				counts.New++
+			default:
+				counts.Updated++
+			}
+		}
+		return counts, errors.Join(errs...)
+	}
+
+	var errs []error
+	valid := make([]*models.Listing, 0, len(listings))
+	ids := make([]string, 0, len(listings))
+	for _, listing := range listings {
+		if listing != nil && listing.ID == "" {
+			listing.ID = p.opts.IDStrategy.ID(listing)
+		}
+		if err := listing.Validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if p.isDeadLettered(listing.ID) {
+			counts.Skipped++
+			continue
+		}
+		valid = append(valid, listing)
+		ids = append(ids, listing.ID)
+	}
+	listings = valid
+
+	now := p.now()
+
+	// Recently saved listings are known without asking the store
+	stored := make(map[string]*models.Listing, len(ids))
+	var misses []string
+	for _, id := range ids {
+		if cached, ok := p.seen.Get(id, now); ok {
+			stored[id] = cached
+			continue
+		}
+		misses = append(misses, id)
+	}
+
+	storedValues, err := batch.GetMany(misses)
+	if err != nil {
+		return counts, fmt.Errorf("failed to check existing listings: %w", err)
+	}
+	for id, value := range storedValues {
+		// An unreadable record still exists but has no first-seen time to keep
+		listing, err := models.FromJSON([]byte(value))
+		if err != nil {
+			listing = nil
+		}
+		stored[id] = listing
+	}
+
+	var unstored []string
+	for _, id := range ids {
+		if _, exists := stored[id]; !exists {
+			unstored = append(unstored, id)
+		}
+	}
+	firstSeen := p.loadFirstSeenMany(batch, unstored, now)
+
+	values := make(map[string]string, len(listings))
+	isNew := make(map[string]bool, len(listings))
+	changes := make(map[string]*models.ListingChange)
+
+	for _, listing := range listings {
+		storedListing, exists := stored[listing.ID]
+		if !exists && listing.Status == models.StatusArchived && p.opts.SkipArchived {
+			counts.Skipped++
+			continue
+		}
+
+		// Keep the first-seen time of already stored listings
+		if listing.CreatedAt.IsZero() {
+			listing.CreatedAt = now
+		}
+		if seen, ok := firstSeen[listing.ID]; ok {
+			listing.CreatedAt = seen
+		}
+		listing.ObservedCount = 1
+		if storedListing != nil {
+			listing.CreatedAt = storedListing.CreatedAt
+			listing.ObservedCount = observedCount(storedListing) + 1
+			listing.Tracked = storedListing.Tracked
+			if listing.Status == models.StatusArchived && storedListing.Status != models.StatusArchived {
+				log.Printf("Listing archived: %s - %s", listing.Title, listing.Price)
+			}
+			if change := models.NewListingChange(storedListing, p.project(listing)); change != nil {
+				changes[listing.ID] = change
+			}
+		}
+		listing.UpdatedAt = now
+		listing.LastSeenAt = now
+
+		data, err := p.project(listing).ToJSON()
+		if err != nil {
+			p.recordSaveFailure(db, listing, err)
+			errs = append(errs, fmt.Errorf("failed to convert listing %s to JSON: %w", listing.ID, err))
+			continue
+		}
+		values[listing.ID] = string(data)
+		isNew[listing.ID] = isNewListing(exists, listing.CreatedAt, now, p.opts.NewWindow)
+	}
+
+	incoming := 0
+	for id := range values {
+		if _, exists := stored[id]; !exists {
+			incoming++
+		}
+	}
+	p.makeRoom(db, incoming)
+
+	failed, err := batch.SetMany(values, listingTTL)
+	if database.IsTransient(err) {
+		return counts, fmt.Errorf("failed to save listings to Redis: %w", err)
+	}
+
+	saved := make([]*models.Listing, 0, len(values))
+	for _, listing := range listings {
+		if _, ok := values[listing.ID]; !ok {
+			continue
+		}
+
+		if saveErr := failed[listing.ID]; saveErr != nil {
+			p.recordSaveFailure(db, listing, saveErr)
+			errs = append(errs, fmt.Errorf("failed to save listing %s to Redis: %w", listing.ID, saveErr))
+			continue
+		}
+		p.failures.Clear(listing.ID)
+		saved = append(saved, listing)
+		p.seen.Add(p.project(listing), now.Add(listingTTL))
+
+		if change := changes[listing.ID]; change != nil {
+			log.Printf("Listing %s changed: %s", listing.ID, change)
+		}
+
+		indexFingerprint(db, listing, isNew[listing.ID])
+		if isNew[listing.ID] {
+			counts.New++
+			log.Printf("Saved listing: %s - %s", listing.Title, listing.Price)
+		} else {
+			counts.Updated++
+		}
+	}
+
+	p.recordFirstSeenMany(batch, saved)
+	p.indexStored(db, saved)
+
+	return counts, errors.Join(errs...)
+}
@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"avito-parser/internal/clock"
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// memStore is an in-memory BatchStore for tests. SetMany fails the keys in
+// failKeys with their error and records the keys of every call. While down is
+// set, the batch calls fail with it.
+type memStore struct {
+	mu       sync.Mutex
+	values   map[string]string
+	failKeys map[string]error
+	setCalls [][]string
+	down     error
+}
+
+func newMemStore() *memStore {
+	return &memStore{values: make(map[string]string), failKeys: make(map[string]error)}
+}
+
+func (m *memStore) Set(key, value string, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value
+	return nil
+}
+
+func (m *memStore) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func (m *memStore) Exists(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.values[key]
+	return ok, nil
+}
+
+func (m *memStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+func (m *memStore) All() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var values []string
+	for key, value := range m.values {
+		if strings.HasPrefix(key, database.ListingKeyPrefix) {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+func (m *memStore) DeleteWhere(pred func(*models.Listing) bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := 0
+	for key, value := range m.values {
+		listing, err := models.FromJSON([]byte(value))
+		if err != nil || !strings.HasPrefix(key, database.ListingKeyPrefix) || !pred(listing) {
+			continue
+		}
+		delete(m.values, key)
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (m *memStore) Close() error {
+	return nil
+}
+
+func (m *memStore) GetMany(keys []string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down != nil {
+		return nil, m.down
+	}
+	values := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := m.values[key]; ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (m *memStore) SetMany(values map[string]string, expiration time.Duration) (map[string]error, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.down != nil {
+		return nil, m.down
+	}
+	failed := make(map[string]error)
+	var keys []string
+	for key, value := range values {
+		keys = append(keys, key)
+		if err := m.failKeys[key]; err != nil {
+			failed[key] = err
+			continue
+		}
+		m.values[key] = value
+	}
+	m.setCalls = append(m.setCalls, keys)
+	return failed, nil
+}
+
+// testListing returns a valid listing with the id listing_<n>
+func testListing(n int) *models.Listing {
+	return &models.Listing{
+		ID:    fmt.Sprintf("listing_%d", n),
+		Title: fmt.Sprintf("Flat %d", n),
+		URL:   fmt.Sprintf("https://www.avito.ru/chelyabinsk/kvartiry/flat_%d", n),
+		Price: "40 000 ₽",
+	}
+}
+
+// testNow is the time of the fake clock of test parsers
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestParser returns a parser saving to db with a fake clock at testNow
+func newTestParser(db database.Store, opts Options) *AvitoParser {
+	if opts.Clock == nil {
+		opts.Clock = clock.NewFake(testNow)
+	}
+	return NewAvitoParser(db, true, time.Second, "", 0, 0, opts)
+}
+
+func TestSaveBatchPartialFailure(t *testing.T) {
+	db := newMemStore()
+	db.failKeys["listing_2"] = errors.New("OOM command not allowed")
+	p := newTestParser(db, Options{SeenCacheSize: 10, SaveMaxAttempts: 3})
+
+	counts, err := p.saveBatch(context.Background(), []*models.Listing{testListing(1), testListing(2), testListing(3)})
+	if err == nil || !strings.Contains(err.Error(), "listing_2") {
+		t.Errorf("saveBatch() error = %v, want the failure of listing_2", err)
+	}
+	if counts != (saveCounts{New: 2}) {
+		t.Errorf("saveBatch() = %+v, want 2 new", counts)
+	}
+
+	for _, id := range []string{"listing_1", "listing_3"} {
+		if exists, _ := db.Exists(id); !exists {
+			t.Errorf("%s was not saved", id)
+		}
+		if _, ok := p.seen.Get(id, testNow); !ok {
+			t.Errorf("%s is not in the seen cache", id)
+		}
+	}
+	if _, ok := p.seen.Get("listing_2", testNow); ok {
+		t.Error("failed listing_2 is in the seen cache")
+	}
+	if n := p.failures.Count("listing_2"); n != 1 {
+		t.Errorf("failures of listing_2 = %d, want 1", n)
+	}
+}
+
+func TestSaveBatchSkipsDeadLettered(t *testing.T) {
+	db := newMemStore()
+	db.failKeys["listing_2"] = errors.New("OOM command not allowed")
+	p := newTestParser(db, Options{SaveMaxAttempts: 2})
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if _, err := p.saveBatch(context.Background(), []*models.Listing{testListing(1), testListing(2)}); err == nil {
+			t.Fatalf("saveBatch() attempt %d error = nil, want the failure of listing_2", attempt)
+		}
+	}
+	if exists, _ := db.Exists(database.DeadLetterKey("listing_2")); !exists {
+		t.Fatal("listing_2 was not dead-lettered after 2 failed saves")
+	}
+
+	db.setCalls = nil
+	counts, err := p.saveBatch(context.Background(), []*models.Listing{testListing(1), testListing(2)})
+	if err != nil {
+		t.Fatalf("saveBatch() error = %v, want the dead-lettered listing skipped", err)
+	}
+	if counts != (saveCounts{Updated: 1, Skipped: 1}) {
+		t.Errorf("saveBatch() = %+v, want 1 updated and 1 skipped", counts)
+	}
+	for _, keys := range db.setCalls {
+		for _, key := range keys {
+			if key == "listing_2" {
+				t.Errorf("dead-lettered listing_2 was written again")
+			}
+		}
+	}
+}
+
+func TestSaveBatchLoadsFirstSeen(t *testing.T) {
+	db := newMemStore()
+	db.Set(database.FirstSeenKey("listing_1"), testNow.Add(-48*time.Hour).Format(time.RFC3339), 0)
+	db.Set(database.FirstSeenKey("listing_2"), testNow.Add(-time.Hour).Format(time.RFC3339), 0)
+	p := newTestParser(db, Options{NewWindow: 24 * time.Hour})
+
+	counts, err := p.saveBatch(context.Background(), []*models.Listing{testListing(1), testListing(2), testListing(3)})
+	if err != nil {
+		t.Fatalf("saveBatch() error = %v", err)
+	}
+	if counts != (saveCounts{New: 2, Updated: 1}) {
+		t.Errorf("saveBatch() = %+v, want listing_1 first seen outside the window updated and 2 new", counts)
+	}
+
+	tests := []struct {
+		id        string
+		firstSeen time.Time
+	}{
+		{"listing_1", testNow.Add(-48 * time.Hour)},
+		{"listing_2", testNow.Add(-time.Hour)},
+		{"listing_3", testNow},
+	}
+	for _, tt := range tests {
+		listing, err := loadListing(db, tt.id)
+		if err != nil || listing == nil {
+			t.Fatalf("loadListing(%q) = %v, %v", tt.id, listing, err)
+		}
+		if !listing.CreatedAt.Equal(tt.firstSeen) {
+			t.Errorf("CreatedAt of %s = %v, want %v", tt.id, listing.CreatedAt, tt.firstSeen)
+		}
+		value, _ := db.Get(database.FirstSeenKey(tt.id))
+		if value != tt.firstSeen.Format(time.RFC3339) {
+			t.Errorf("recorded first-seen time of %s = %q, want %v", tt.id, value, tt.firstSeen)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// errBlocked is returned when Avito answers with a captcha or access-denied page
+var errBlocked = errors.New("page blocked by Avito")
+
+// bodyText returns the page's visible text, cut to the configured scan limit
+func (p *AvitoParser) bodyText(page *rod.Page) (string, bool) {
+	body, err := page.Sleeper(rod.NotFoundSleeper).Element("body")
+	if err != nil || body == nil {
+		return "", false
+	}
+
+	text, err := body.Text()
+	if err != nil {
+		return "", false
+	}
+	return truncateRunes(text, p.opts.BodyScanLimit), true
+}
+
+// blockKeywordsIn returns the configured block keywords found in the text
+func (p *AvitoParser) blockKeywordsIn(text string) []string {
+	text = strings.ToLower(text)
+
+	var found []string
+	for _, keyword := range p.opts.BlockKeywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			found = append(found, keyword)
+		}
+	}
+	return found
+}
+
+// isBlocked checks the page for Avito's captcha or access-denied markers
+func (p *AvitoParser) isBlocked(page *rod.Page) bool {
+	text, ok := p.bodyText(page)
+	return ok && len(p.blockKeywordsIn(text)) > 0
+}
+
+// truncateRunes cuts s to at most limit characters, 0 keeps it whole
+func truncateRunes(s string, limit int) string {
+	if limit <= 0 {
+		return s
+	}
+
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// Block rate alert transitions reported by blockRate.Record
+const (
+	blockAlertNone = iota
+	blockAlertRaised
+	blockAlertCleared
+)
+
+// blockRate tracks which of the last page loads were blocked. It raises an
+// alert once when the blocked share of a full window exceeds the threshold
+// and clears it when the share drops back, so each episode is reported once.
+// It is only used from the parsing cycle and needs no locking.
+type blockRate struct {
+	window    []bool
+	next      int
+	filled    int
+	threshold float64
+	alerting  bool
+}
+
+// newBlockRate tracks the last size page loads, nil when alerts are disabled
+func newBlockRate(size int, threshold float64) *blockRate {
+	if size <= 0 || threshold <= 0 {
+		return nil
+	}
+	return &blockRate{window: make([]bool, size), threshold: threshold}
+}
+
+// Record adds a page load and returns the blocked share of the window with the
+// alert transition it caused
+func (b *blockRate) Record(blocked bool) (float64, int) {
+	b.window[b.next] = blocked
+	b.next = (b.next + 1) % len(b.window)
+	if b.filled < len(b.window) {
+		b.filled++
+	}
+
+	count := 0
+	for _, wasBlocked := range b.window[:b.filled] {
+		if wasBlocked {
+			count++
+		}
+	}
+	rate := float64(count) / float64(b.filled)
+
+	switch {
+	case !b.alerting && b.filled == len(b.window) && rate > b.threshold:
+		b.alerting = true
+		return rate, blockAlertRaised
+	case b.alerting && rate <= b.threshold:
+		b.alerting = false
+		return rate, blockAlertCleared
+	}
+	return rate, blockAlertNone
+}
+
+// recordPageLoad feeds the outcome of a page load to the block rate alert,
+// pausing for BlockAlertCooldown when the alert is raised. Failures other
+// than blocks say nothing about blocking and are ignored.
+func (p *AvitoParser) recordPageLoad(ctx context.Context, err error) {
+	if p.blocks == nil || (err != nil && !errors.Is(err, errBlocked)) {
+		return
+	}
+
+	rate, transition := p.blocks.Record(err != nil)
+	switch transition {
+	case blockAlertRaised:
+		log.Printf("🚨 ALERT: %.0f%% of the last %d page loads were blocked (threshold %.0f%%)", rate*100, len(p.blocks.window), p.blocks.threshold*100)
+		if p.opts.BlockAlertCooldown > 0 {
+			log.Printf("Pausing parsing for %v to let the block cool down", p.opts.BlockAlertCooldown)
+			sleep(ctx, p.opts.BlockAlertCooldown)
+		}
+	case blockAlertCleared:
+		log.Printf("Block rate recovered to %.0f%% of the last %d page loads", rate*100, len(p.blocks.window))
+	}
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// errBuffered is returned when a listing is kept in memory because Redis is unavailable
+var errBuffered = errors.New("listing buffered until Redis recovers")
+
+// saveBatchOrBuffer saves the listings, falling back to the in-memory buffer while Redis is down
+func (p *AvitoParser) saveBatchOrBuffer(ctx context.Context, listings []*models.Listing) (saveCounts, error) {
+	// Keep order: while older listings wait in the buffer, new ones queue behind them
+	if len(p.pending) > 0 {
+		for _, listing := range listings {
+			p.buffer(listing)
+		}
+		return saveCounts{}, errBuffered
+	}
+
+	counts, err := p.saveBatch(ctx, listings)
+	if database.IsTransient(err) {
+		log.Printf("Redis unavailable, buffering listings in memory: %v", err)
+		for _, listing := range listings {
+			p.buffer(listing)
+		}
+		return saveCounts{}, errBuffered
+	}
+	return counts, err
+}
+
+// buffer keeps the listing for a later flush, dropping the oldest one when full
+func (p *AvitoParser) buffer(listing *models.Listing) {
+	if p.opts.BufferSize <= 0 {
+		log.Printf("Dropping listing %s: Redis unavailable and buffering disabled", listing.ID)
+		return
+	}
+
+	if len(p.pending) >= p.opts.BufferSize {
+		log.Printf("Listing buffer full (%d), dropping oldest listing %s", p.opts.BufferSize, p.pending[0].ID)
+		p.pending = p.pending[1:]
+	}
+	p.pending = append(p.pending, listing)
+}
+
+// flushPending writes buffered listings to Redis and returns how many were new.
+// On a transient error the buffer is kept for the next try.
+func (p *AvitoParser) flushPending(ctx context.Context) int {
+	if len(p.pending) == 0 {
+		return 0
+	}
+
+	counts, err := p.saveBatch(ctx, p.pending)
+	if database.IsTransient(err) {
+		log.Printf("Redis still unavailable, %d listings remain buffered", len(p.pending))
+		return 0
+	}
+	if err != nil {
+		log.Printf("Error saving buffered listings: %v", err)
+	}
+
+	p.pending = nil
+	log.Printf("Redis recovered, flushed buffered listings (%d new)", counts.New)
+	return counts.New
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"avito-parser/internal/models"
+)
+
+// pendingIDs returns the ids of the buffered listings in order
+func pendingIDs(p *AvitoParser) []string {
+	var ids []string
+	for _, listing := range p.pending {
+		ids = append(ids, listing.ID)
+	}
+	return ids
+}
+
+func TestBufferDropsOldestAndFlushes(t *testing.T) {
+	ctx := context.Background()
+	db := newMemStore()
+	db.down = io.EOF
+	p := newTestParser(db, Options{BufferSize: 3})
+
+	if _, err := p.saveBatchOrBuffer(ctx, []*models.Listing{testListing(1), testListing(2)}); !errors.Is(err, errBuffered) {
+		t.Fatalf("saveBatchOrBuffer() error = %v, want %v", err, errBuffered)
+	}
+	if _, err := p.saveBatchOrBuffer(ctx, []*models.Listing{testListing(3), testListing(4)}); !errors.Is(err, errBuffered) {
+		t.Fatalf("saveBatchOrBuffer() error = %v, want %v", err, errBuffered)
+	}
+	want := []string{"listing_2", "listing_3", "listing_4"}
+	if got := pendingIDs(p); !reflect.DeepEqual(got, want) {
+		t.Fatalf("buffered %v, want %v with the oldest dropped", got, want)
+	}
+
+	if n := p.flushPending(ctx); n != 0 {
+		t.Errorf("flushPending() while down = %d, want 0", n)
+	}
+	if got := pendingIDs(p); !reflect.DeepEqual(got, want) {
+		t.Fatalf("buffered after a failed flush %v, want %v kept", got, want)
+	}
+
+	db.down = nil
+	// Listings saved while others wait queue behind them
+	if _, err := p.saveBatchOrBuffer(ctx, []*models.Listing{testListing(5)}); !errors.Is(err, errBuffered) {
+		t.Fatalf("saveBatchOrBuffer() error = %v, want %v", err, errBuffered)
+	}
+	want = []string{"listing_3", "listing_4", "listing_5"}
+	if got := pendingIDs(p); !reflect.DeepEqual(got, want) {
+		t.Fatalf("buffered %v, want %v", got, want)
+	}
+
+	if n := p.flushPending(ctx); n != 3 {
+		t.Errorf("flushPending() = %d, want 3 new", n)
+	}
+	if len(p.pending) != 0 {
+		t.Errorf("buffered after flush %v, want none", pendingIDs(p))
+	}
+	for id, stored := range map[string]bool{"listing_1": false, "listing_2": false, "listing_3": true, "listing_4": true, "listing_5": true} {
+		if exists, _ := db.Exists(id); exists != stored {
+			t.Errorf("%s stored = %v, want %v", id, exists, stored)
+		}
+	}
+}
+
+func TestBufferDisabled(t *testing.T) {
+	db := newMemStore()
+	db.down = io.EOF
+	p := newTestParser(db, Options{})
+
+	if _, err := p.saveBatchOrBuffer(context.Background(), []*models.Listing{testListing(1)}); !errors.Is(err, errBuffered) {
+		t.Fatalf("saveBatchOrBuffer() error = %v, want %v", err, errBuffered)
+	}
+	if len(p.pending) != 0 {
+		t.Errorf("buffered %v, want nothing without a buffer size", pendingIDs(p))
+	}
+}
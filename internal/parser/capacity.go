@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"log"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// makeRoom evicts the listings seen first until incoming new listings fit
+// under MaxStored. Counting drops the ids of expired records from the index,
+// so every evicted id frees a stored listing.
+func (p *AvitoParser) makeRoom(db database.Store, incoming int) {
+	indexer, ok := db.(database.SortedIndexer)
+	if !ok || p.opts.MaxStored <= 0 || incoming <= 0 {
+		return
+	}
+
+	count, err := database.Count(db)
+	if err != nil {
+		log.Printf("Failed to count stored listings: %v", err)
+		return
+	}
+
+	excess := count + int64(incoming) - int64(p.opts.MaxStored)
+	if excess <= 0 {
+		return
+	}
+
+	ids, err := indexer.ZRange(database.StoredIndex, 0, excess-1)
+	if err != nil {
+		log.Printf("Failed to find the oldest listings: %v", err)
+		return
+	}
+
+	evicted := make([]string, 0, len(ids))
+	for _, id := range ids {
+		// A listing that fails to delete stays indexed and is retried next time
+		if err := db.Delete(id); err != nil {
+			log.Printf("Failed to evict listing %s: %v", id, err)
+			continue
+		}
+		p.seen.Remove(id)
+		evicted = append(evicted, id)
+	}
+
+	err = indexer.ZRem(database.StoredIndex, evicted...)
+	if err != nil {
+		log.Printf("Failed to drop evicted listings from the index: %v", err)
+	}
+	log.Printf("Evicted %d oldest listings to keep at most %d stored", len(evicted), p.opts.MaxStored)
+}
+
+// indexStored records saved listings in the eviction index by their first-seen time
+func (p *AvitoParser) indexStored(db database.Store, listings []*models.Listing) {
+	indexer, ok := db.(database.SortedIndexer)
+	if !ok || p.opts.MaxStored <= 0 || len(listings) == 0 {
+		return
+	}
+
+	scores := make(map[string]float64, len(listings))
+	for _, listing := range listings {
+		scores[listing.ID] = float64(listing.CreatedAt.Unix())
+	}
+
+	err := indexer.ZAdd(database.StoredIndex, scores)
+	if err != nil {
+		log.Printf("Failed to index %d stored listings: %v", len(listings), err)
+	}
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"avito-parser/internal/database"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newTestRedis connects a Redis client to an in-memory server
+func newTestRedis(t *testing.T) *database.RedisClient {
+	t.Helper()
+	server := miniredis.RunT(t)
+	db, err := database.NewRedisClientWithTimeout(server.Host(), server.Port(), "", 0, false, false, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMakeRoom(t *testing.T) {
+	tests := []struct {
+		name      string
+		stored    int
+		maxStored int
+		incoming  int
+		evicted   []string
+	}{
+		{name: "room left", stored: 3, maxStored: 5, incoming: 2},
+		{name: "full", stored: 5, maxStored: 5, incoming: 2, evicted: []string{"listing_1", "listing_2"}},
+		{name: "over the limit", stored: 5, maxStored: 3, incoming: 1, evicted: []string{"listing_1", "listing_2", "listing_3"}},
+		{name: "nothing incoming", stored: 5, maxStored: 3, incoming: 0},
+		{name: "no limit", stored: 5, maxStored: 0, incoming: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestRedis(t)
+			p := newTestParser(db, Options{MaxStored: tt.maxStored, SeenCacheSize: 10})
+			// Listings are first seen in order, so listing_1 is the oldest
+			scores := make(map[string]float64)
+			for n := 1; n <= tt.stored; n++ {
+				listing := testListing(n)
+				data, err := listing.ToJSON()
+				if err != nil {
+					t.Fatal(err)
+				}
+				db.Set(listing.ID, string(data), time.Hour)
+				scores[listing.ID] = float64(n)
+				p.seen.Add(listing, testNow.Add(time.Hour))
+			}
+			db.ZAdd(database.StoredIndex, scores)
+
+			p.makeRoom(db, tt.incoming)
+
+			var evicted []string
+			for n := 1; n <= tt.stored; n++ {
+				id := fmt.Sprintf("listing_%d", n)
+				if exists, _ := db.Exists(id); !exists {
+					evicted = append(evicted, id)
+					if _, ok := p.seen.Get(id, testNow); ok {
+						t.Errorf("evicted %s is still in the seen cache", id)
+					}
+				}
+			}
+			if !reflect.DeepEqual(evicted, tt.evicted) {
+				t.Errorf("makeRoom(%d) evicted %v, want %v", tt.incoming, evicted, tt.evicted)
+			}
+
+			kept := int64(tt.stored - len(tt.evicted))
+			if indexed, _ := db.ZRange(database.StoredIndex, 0, -1); int64(len(indexed)) != kept {
+				t.Errorf("index holds %v, want the %d kept listings", indexed, kept)
+			}
+			if count, _ := db.Count(); count != kept {
+				t.Errorf("Count() = %d, want %d", count, kept)
+			}
+		})
+	}
+}
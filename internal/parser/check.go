@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod"
+)
+
+// SelectorReport is how many elements one selector matched on a live page
+type SelectorReport struct {
+	Set      string `json:"set"`
+	Selector string `json:"selector"`
+	Matches  int    `json:"matches"`
+	Err      error  `json:"-"`
+}
+
+// selectorSet is a named group of selectors the parser uses together
+type selectorSet struct {
+	name      string
+	selectors []string
+}
+
+// cardSelectorSets are looked up inside a listing card rather than the whole page
+func (p *AvitoParser) cardSelectorSets() []selectorSet {
+	return []selectorSet{
+		{"title", p.selectorsFor("title", titleSelectors)},
+		{"price", p.selectorsFor("price", priceSelectors)},
+		{"date", p.selectorsFor("date", dateSelectors)},
+		{"location", p.selectorsFor("location", locationSelectors)},
+		{"metro", p.selectorsFor("metro", metroSelectors)},
+		{"badge", p.selectorsFor("badge", badgeSelectors)},
+	}
+}
+
+// CheckSelectors loads the base URL and counts the matches of every selector
+// the parser uses. Listing and pagination selectors are counted on the page,
+// field selectors inside the first listing card. An error is returned when no
+// listing selector matches, which means the parser would find nothing.
+func (p *AvitoParser) CheckSelectors() ([]SelectorReport, error) {
+	page, err := p.openPage(p.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page: %w", err)
+	}
+	defer page.Close()
+
+	err = page.WaitLoad()
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for page load: %w", err)
+	}
+
+	// Wait for dynamic content
+	p.waitForContent(page, p.opts.ContentWait)
+
+	if p.isBlocked(page) {
+		return nil, errBlocked
+	}
+
+	reports, ok := p.selectorReports(page)
+	if !ok {
+		return reports, fmt.Errorf("no listing selector matched on %s", p.baseURL)
+	}
+	return reports, nil
+}
+
+// selectorReports counts every selector on an opened search page and reports
+// whether any listing card was found to count the field selectors in
+func (p *AvitoParser) selectorReports(page *rod.Page) ([]SelectorReport, bool) {
+	reports := countSelectors(page, selectorSet{"listing", p.selectorsFor("listing", listingSelectors)})
+	reports = append(reports, countSelectors(page, selectorSet{"pagination", []string{paginationSelector}})...)
+
+	var card *rod.Element
+	for _, report := range reports {
+		if report.Set == "listing" && report.Matches > 0 {
+			found, err := page.Element(report.Selector)
+			if err == nil {
+				card = found
+				break
+			}
+		}
+	}
+	if card == nil {
+		return reports, false
+	}
+
+	for _, set := range p.cardSelectorSets() {
+		reports = append(reports, countSelectors(card, set)...)
+	}
+	return reports, true
+}
+
+// elementFinder is what selectors are counted in, a page or a card element
+type elementFinder interface {
+	Elements(selector string) (rod.Elements, error)
+}
+
+// countSelectors reports the match count of each selector of the set
+func countSelectors(root elementFinder, set selectorSet) []SelectorReport {
+	reports := make([]SelectorReport, 0, len(set.selectors))
+	for _, selector := range set.selectors {
+		elements, err := root.Elements(selector)
+		reports = append(reports, SelectorReport{
+			Set:      set.name,
+			Selector: selector,
+			Matches:  len(elements),
+			Err:      err,
+		})
+	}
+	return reports
+}
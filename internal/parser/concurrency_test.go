@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+// lockCheckedStore fails the test when it is used while cycleMu isn't held
+type lockCheckedStore struct {
+	*memStore
+	t *testing.T
+	p *AvitoParser
+}
+
+func (s *lockCheckedStore) check() {
+	if s.p.cycleMu.TryLock() {
+		s.p.cycleMu.Unlock()
+		s.t.Error("store used without cycleMu held")
+	}
+}
+
+func (s *lockCheckedStore) Set(key, value string, expiration time.Duration) error {
+	s.check()
+	return s.memStore.Set(key, value, expiration)
+}
+
+func (s *lockCheckedStore) Get(key string) (string, error) {
+	s.check()
+	return s.memStore.Get(key)
+}
+
+func (s *lockCheckedStore) Exists(key string) (bool, error) {
+	s.check()
+	return s.memStore.Exists(key)
+}
+
+func (s *lockCheckedStore) Delete(key string) error {
+	s.check()
+	return s.memStore.Delete(key)
+}
+
+func (s *lockCheckedStore) All() ([]string, error) {
+	s.check()
+	return s.memStore.All()
+}
+
+func (s *lockCheckedStore) DeleteWhere(pred func(*models.Listing) bool) (int, error) {
+	s.check()
+	return s.memStore.DeleteWhere(pred)
+}
+
+func (s *lockCheckedStore) GetMany(keys []string) (map[string]string, error) {
+	s.check()
+	return s.memStore.GetMany(keys)
+}
+
+func (s *lockCheckedStore) SetMany(values map[string]string, expiration time.Duration) (map[string]error, error) {
+	s.check()
+	return s.memStore.SetMany(values, expiration)
+}
+
+// Run with -race: the refresh, the watch list check, a purge and the search
+// cycle share the store, the seen cache and the buffer, and must take turns.
+func TestConcurrentRunsTakeTurns(t *testing.T) {
+	store := &lockCheckedStore{memStore: newMemStore(), t: t}
+	p := newTestParser(store, Options{
+		SeenCacheSize: 10,
+		Watchlist:     []string{"1", "2"},
+	})
+	store.p = p
+
+	for n := 1; n <= 3; n++ {
+		listing := testListing(n)
+		data, err := listing.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.memStore.Set(listing.ID, string(data), 0)
+	}
+
+	var inFlight, overlaps atomic.Int64
+	p.details = func(ctx context.Context, listingURL string) (*listingDetail, error) {
+		if inFlight.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		defer inFlight.Add(-1)
+		time.Sleep(time.Millisecond)
+		return &listingDetail{Title: "Flat", Price: "45 000 ₽"}, nil
+	}
+
+	ctx := context.Background()
+	runs := map[string]func(){
+		"refresh":   func() { p.RefreshAll(ctx) },
+		"watchlist": func() { p.CheckWatchlist(ctx) },
+		"purge": func() {
+			p.Purge(func(listing *models.Listing) bool { return listing.ID == "listing_3" })
+		},
+		// Without a browser the cycle fails every page, but still holds the
+		// lock while it reads the selector baseline and retries
+		"cycle": func() { p.ParseAllPages(ctx) },
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		for _, run := range runs {
+			wg.Add(1)
+			go func(run func()) {
+				defer wg.Done()
+				run()
+			}(run)
+		}
+	}
+	wg.Wait()
+
+	if n := overlaps.Load(); n > 0 {
+		t.Errorf("%d listing pages were fetched while another run was fetching", n)
+	}
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+func TestCycleContext(t *testing.T) {
+	tests := []struct {
+		timeout  time.Duration
+		deadline bool
+	}{
+		{0, false},
+		{-time.Second, false},
+		{time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		p := newTestParser(newMemStore(), Options{CycleTimeout: tt.timeout})
+		ctx, cancel := p.cycleContext(context.Background())
+		if _, ok := ctx.Deadline(); ok != tt.deadline {
+			t.Errorf("cycleContext() with timeout %v has deadline %v, want %v", tt.timeout, ok, tt.deadline)
+		}
+		cancel()
+		if ctx.Err() == nil {
+			t.Errorf("cycleContext() with timeout %v isn't cancelled by its cancel func", tt.timeout)
+		}
+	}
+}
+
+// A slow second page runs past the cycle timeout: the cycle stops with the
+// listings of the first page and those the slow page gave before it saved
+func TestCycleTimeoutSavesWhatWasParsed(t *testing.T) {
+	db := newMemStore()
+	p := newTestParser(db, Options{CycleTimeout: 100 * time.Millisecond})
+	p.baseURL = searchURL
+
+	var probed []int
+	p.prober = func(pageURL string, countSelectors, readTotal bool) (*pageProbe, error) {
+		probed = append(probed, pageNumber(pageURL))
+		return &pageProbe{HasListings: true, Count: 3}, nil
+	}
+	p.lister = func(ctx context.Context, pageURL string) ([]*models.Listing, error) {
+		if pageNumber(pageURL) == 1 {
+			return []*models.Listing{testListing(1), testListing(2), testListing(3)}, nil
+		}
+		// One card is parsed before the page stalls
+		<-ctx.Done()
+		return []*models.Listing{testListing(4)}, ctx.Err()
+	}
+
+	ctx, cancel := p.cycleContext(context.Background())
+	defer cancel()
+	start := time.Now()
+	result, err := p.ParseAllPages(ctx)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ParseAllPages() error = %v, want the cycle timeout", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("ParseAllPages() took %v, want it to stop at the 100ms timeout", elapsed)
+	}
+	if len(probed) != 2 {
+		t.Errorf("probed pages %v, want no page after the slow one", probed)
+	}
+	if result.Pages != 2 || result.New != 4 {
+		t.Errorf("result = %d pages, %d new, want 2 pages, 4 new", result.Pages, result.New)
+	}
+
+	var stored []string
+	for key := range db.values {
+		stored = append(stored, key)
+	}
+	sort.Strings(stored)
+	want := []string{"listing_1", "listing_2", "listing_3", "listing_4"}
+	for _, id := range want {
+		if _, ok := db.values[id]; !ok {
+			t.Errorf("%s was not saved, stored %v", id, stored)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bumpMarkers are phrases in the card date that mean the seller re-promoted the listing
+var bumpMarkers = []string{
+	"поднят",
+}
+
+// relativeAgoPattern matches phrases like "3 часа назад" or "час назад"
+var relativeAgoPattern = regexp.MustCompile(`(\d+)?\s*(\p{L}+)\s+назад`)
+
+// clockPattern matches a time of day like "14:30"
+var clockPattern = regexp.MustCompile(`(\d{1,2}):(\d{2})`)
+
+// absoluteDatePattern matches dates like "12 марта" or "12 марта 2023"
+var absoluteDatePattern = regexp.MustCompile(`(\d{1,2})\s+(\p{L}+)(?:\s+(\d{4}))?`)
+
+// monthPrefixes maps genitive month name prefixes to months
+var monthPrefixes = []struct {
+	prefix string
+	month  time.Month
+}{
+	{"январ", time.January},
+	{"феврал", time.February},
+	{"март", time.March},
+	{"апрел", time.April},
+	{"ма", time.May},
+	{"июн", time.June},
+	{"июл", time.July},
+	{"август", time.August},
+	{"сентябр", time.September},
+	{"октябр", time.October},
+	{"ноябр", time.November},
+	{"декабр", time.December},
+}
+
+// parseBumped reports whether the date text indicates a re-published listing
+// and, when the time of re-publication can be determined, when it happened
+func parseBumped(dateText string, now time.Time) (bool, time.Time) {
+	text := strings.ToLower(strings.TrimSpace(dateText))
+
+	bumped := false
+	for _, marker := range bumpMarkers {
+		if strings.Contains(text, marker) {
+			bumped = true
+			break
+		}
+	}
+
+	if !bumped {
+		return false, time.Time{}
+	}
+
+	bumpedAt, ok := parseRelativeDate(text, now)
+	if !ok {
+		return true, time.Time{}
+	}
+	return true, bumpedAt
+}
+
+// parseRelativeDate converts Avito's relative date phrases ("сегодня", "вчера",
+// "5 минут назад") into a time relative to now
+func parseRelativeDate(text string, now time.Time) (time.Time, bool) {
+	text = strings.ToLower(text)
+
+	switch {
+	case strings.Contains(text, "только что"):
+		return now, true
+	case strings.Contains(text, "позавчера"):
+		return atClock(now.AddDate(0, 0, -2), text), true
+	case strings.Contains(text, "вчера"):
+		return atClock(now.AddDate(0, 0, -1), text), true
+	case strings.Contains(text, "сегодня"):
+		return atClock(now, text), true
+	}
+
+	match := relativeAgoPattern.FindStringSubmatch(text)
+	if match == nil {
+		return parseAbsoluteDate(text, now)
+	}
+
+	amount := 1
+	if match[1] != "" {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return time.Time{}, false
+		}
+		amount = n
+	}
+
+	unit := match[2]
+	switch {
+	case strings.HasPrefix(unit, "секунд"):
+		return now.Add(-time.Duration(amount) * time.Second), true
+	case strings.HasPrefix(unit, "минут"):
+		return now.Add(-time.Duration(amount) * time.Minute), true
+	case strings.HasPrefix(unit, "час"):
+		return now.Add(-time.Duration(amount) * time.Hour), true
+	case strings.HasPrefix(unit, "дн"), strings.HasPrefix(unit, "день"):
+		return now.AddDate(0, 0, -amount), true
+	case strings.HasPrefix(unit, "недел"):
+		return now.AddDate(0, 0, -7*amount), true
+	case strings.HasPrefix(unit, "месяц"):
+		return now.AddDate(0, -amount, 0), true
+	}
+
+	return time.Time{}, false
+}
+
+// atClock sets the time of day from text like "вчера в 14:30", keeping day as is otherwise
+func atClock(day time.Time, text string) time.Time {
+	match := clockPattern.FindStringSubmatch(text)
+	if match == nil {
+		return day
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	if hour > 23 || minute > 59 {
+		return day
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
+
+// parseAbsoluteDate handles dates like "12 марта" or "12 марта 2023 10:15".
+// Without a year, a date later than now is taken from the previous year.
+func parseAbsoluteDate(text string, now time.Time) (time.Time, bool) {
+	match := absoluteDatePattern.FindStringSubmatch(text)
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	day, err := strconv.Atoi(match[1])
+	if err != nil || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+
+	var month time.Month
+	for _, m := range monthPrefixes {
+		if strings.HasPrefix(match[2], m.prefix) {
+			month = m.month
+			break
+		}
+	}
+	if month == 0 {
+		return time.Time{}, false
+	}
+
+	year := now.Year()
+	if match[3] != "" {
+		year, _ = strconv.Atoi(match[3])
+	}
+
+	date := atClock(time.Date(year, month, day, 0, 0, 0, 0, now.Location()), text)
+	if match[3] == "" && date.After(now) {
+		date = date.AddDate(-1, 0, 0)
+	}
+	return date, true
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// deadLetterTTL is how long a dead-lettered listing stays recorded
+const deadLetterTTL = 7 * 24 * time.Hour
+
+// saveFailures counts failed saves of listings that aren't caused by the
+// store being unavailable, so a listing that can never be saved is given up on
+type saveFailures struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// newSaveFailures creates an empty failure count
+func newSaveFailures() *saveFailures {
+	return &saveFailures{counts: make(map[string]int)}
+}
+
+// Add counts a failure of the listing and returns its failures so far
+func (f *saveFailures) Add(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.counts[id]++
+	return f.counts[id]
+}
+
+// Count returns the failures of the listing so far
+func (f *saveFailures) Count(id string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.counts[id]
+}
+
+// Clear forgets the failures of a listing that was saved
+func (f *saveFailures) Clear(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.counts, id)
+}
+
+// isDeadLettered reports whether the listing failed to save SaveMaxAttempts
+// times and is no longer tried
+func (p *AvitoParser) isDeadLettered(id string) bool {
+	return p.opts.SaveMaxAttempts > 0 && p.failures.Count(id) >= p.opts.SaveMaxAttempts
+}
+
+// recordSaveFailure counts a failed save of the listing. Once it reaches
+// SaveMaxAttempts the listing is recorded in the dead-letter set with the
+// error and skipped from then on.
+func (p *AvitoParser) recordSaveFailure(db database.Store, listing *models.Listing, saveErr error) {
+	if p.opts.SaveMaxAttempts <= 0 || database.IsTransient(saveErr) {
+		return
+	}
+
+	attempts := p.failures.Add(listing.ID)
+	if attempts != p.opts.SaveMaxAttempts {
+		return
+	}
+
+	log.Printf("Giving up on listing %s after %d failed saves: %v", listing.ID, attempts, saveErr)
+
+	err := db.Set(database.DeadLetterKey(listing.ID), saveErr.Error(), deadLetterTTL)
+	if err != nil {
+		log.Printf("Failed to record dead-lettered listing %s: %v", listing.ID, err)
+		return
+	}
+	if indexer, ok := db.(database.Indexer); ok {
+		err = indexer.AddToIndex(database.DeadLetterIndex, listing.ID, deadLetterTTL)
+		if err != nil {
+			log.Printf("Failed to record dead-lettered listing %s: %v", listing.ID, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"avito-parser/internal/database"
+)
+
+func TestRecordSaveFailure(t *testing.T) {
+	permanent := errors.New("OOM command not allowed")
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		errs        []error
+		dead        bool
+	}{
+		{"below the limit", 3, []error{permanent, permanent}, false},
+		{"at the limit", 3, []error{permanent, permanent, permanent}, true},
+		{"transient errors aren't counted", 3, []error{permanent, io.EOF, io.EOF, permanent, io.EOF}, false},
+		{"mixed up to the limit", 3, []error{io.EOF, permanent, io.EOF, permanent, permanent}, true},
+		{"no limit", 0, []error{permanent, permanent, permanent, permanent}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestRedis(t)
+			p := newTestParser(db, Options{SaveMaxAttempts: tt.maxAttempts})
+			listing := testListing(1)
+
+			for _, err := range tt.errs {
+				p.recordSaveFailure(db, listing, err)
+			}
+
+			if got := p.isDeadLettered(listing.ID); got != tt.dead {
+				t.Errorf("isDeadLettered() = %v, want %v", got, tt.dead)
+			}
+			reason, err := db.Get(database.DeadLetterKey(listing.ID))
+			if recorded := err == nil; recorded != tt.dead {
+				t.Errorf("dead letter recorded = %v, want %v", recorded, tt.dead)
+			} else if tt.dead && reason != permanent.Error() {
+				t.Errorf("dead letter reason = %q, want %q", reason, permanent.Error())
+			}
+			members, _ := db.IndexMembers(database.DeadLetterIndex)
+			if indexed := len(members) == 1 && members[0] == listing.ID; indexed != tt.dead {
+				t.Errorf("dead letter index = %v, want listing_1 indexed %v", members, tt.dead)
+			}
+		})
+	}
+}
+
+func TestSaveFailuresClear(t *testing.T) {
+	db := newTestRedis(t)
+	p := newTestParser(db, Options{SaveMaxAttempts: 2})
+	listing := testListing(1)
+	permanent := errors.New("OOM command not allowed")
+
+	p.recordSaveFailure(db, listing, permanent)
+	p.failures.Clear(listing.ID)
+	p.recordSaveFailure(db, listing, permanent)
+
+	if p.isDeadLettered(listing.ID) {
+		t.Error("isDeadLettered() = true, want the count reset by a successful save")
+	}
+}
@@ -3,9 +3,6 @@ package parser
 import (
 	"log"
 	"time"
-
-	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/proto"
 )
 
 // DebugPage analyzes page structure for debugging
@@ -13,7 +10,7 @@ func (p *AvitoParser) DebugPage(url string) error {
 	log.Printf("=== DEBUG MODE: Analyzing page structure ===")
 	log.Printf("URL: %s", url)
 
-	page, err := p.browser.Page(proto.TargetCreateTarget{URL: url})
+	page, err := p.openPage(url)
 	if err != nil {
 		return err
 	}
@@ -31,9 +28,9 @@ func (p *AvitoParser) DebugPage(url string) error {
 	time.Sleep(5 * time.Second)
 
 	// Get page title
-	title, err := page.Title()
+	info, err := page.Info()
 	if err == nil {
-		log.Printf("Page title: %s", title)
+		log.Printf("Page title: %s", info.Title)
 	} else {
 		log.Printf("Failed to get page title: %v", err)
 	}
@@ -73,32 +70,12 @@ func (p *AvitoParser) DebugPage(url string) error {
 	}
 
 	// Check if we're blocked or redirected
-	body, err := page.Element("body")
-	if err == nil && body != nil {
-		bodyText, err := body.Text()
-		if err == nil {
-			if len(bodyText) < 500 {
-				log.Printf("Body text (full): %s", bodyText)
-			} else {
-				log.Printf("Body text (first 500 chars): %s...", bodyText[:500])
-			}
-			
-			// Check for common blocking indicators
-			blockingKeywords := []string{
-				"блокировка",
-				"доступ запрещен",
-				"access denied",
-				"captcha",
-				"проверка браузера",
-				"robot",
-				"бот",
-			}
-			
-			for _, keyword := range blockingKeywords {
-				if containsIgnoreCase(bodyText, keyword) {
-					log.Printf("⚠️  WARNING: Page might be blocked - found keyword: %s", keyword)
-				}
-			}
+	bodyText, ok := p.bodyText(page)
+	if ok {
+		log.Printf("Body text (first 500 chars): %s", truncateRunes(bodyText, 500))
+
+		for _, keyword := range p.blockKeywordsIn(bodyText) {
+			log.Printf("⚠️  WARNING: Page might be blocked - found keyword: %s", keyword)
 		}
 	}
 
@@ -113,31 +90,3 @@ func (p *AvitoParser) DebugPage(url string) error {
 	log.Printf("=== END DEBUG ===")
 	return nil
 }
-
-// containsIgnoreCase checks if string contains substring (case insensitive)
-func containsIgnoreCase(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		match := true
-		for j := 0; j < len(substr); j++ {
-			if toLower(s[i+j]) != toLower(substr[j]) {
-				match = false
-				break
-			}
-		}
-		if match {
-			return true
-		}
-	}
-	return false
-}
-
-// toLower converts byte to lowercase
-func toLower(b byte) byte {
-	if b >= 'A' && b <= 'Z' {
-		return b + ('a' - 'A')
-	}
-	if b >= 'А' && b <= 'Я' {
-		return b + ('а' - 'А')
-	}
-	return b
-}
\ No newline at end of file
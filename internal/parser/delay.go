@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Delay distributions between page requests
+const (
+	DelayFixed   = "fixed"
+	DelayUniform = "uniform"
+	DelayNormal  = "normal"
+)
+
+// DelayFunc returns the pause before the next page request
+type DelayFunc func() time.Duration
+
+// NewDelayFunc builds a delay generator around base. fixed always returns base,
+// uniform picks evenly from base±spread and normal draws with mean base and
+// standard deviation spread; random delays never go below floor, nor below 0
+// with a negative floor. Pass a seeded rng for reproducible delays.
+func NewDelayFunc(distribution string, base, spread, floor time.Duration, rng *rand.Rand) (DelayFunc, error) {
+	clamp := func(d time.Duration) time.Duration {
+		return max(d, floor, 0)
+	}
+
+	switch distribution {
+	case DelayFixed, "":
+		return func() time.Duration {
+			return base
+		}, nil
+	case DelayUniform:
+		return func() time.Duration {
+			if spread <= 0 {
+				return clamp(base)
+			}
+			return clamp(base - spread + time.Duration(rng.Int63n(int64(2*spread)+1)))
+		}, nil
+	case DelayNormal:
+		return func() time.Duration {
+			return clamp(base + time.Duration(rng.NormFloat64()*float64(spread)))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown delay distribution %q, expected fixed, uniform or normal", distribution)
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestNewDelayFunc(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution string
+		base         time.Duration
+		spread       time.Duration
+		floor        time.Duration
+		min          time.Duration
+		max          time.Duration
+		clamped      bool
+	}{
+		{"fixed", DelayFixed, 2 * time.Second, time.Second, 0, 2 * time.Second, 2 * time.Second, false},
+		{"default is fixed", "", 2 * time.Second, time.Second, 0, 2 * time.Second, 2 * time.Second, false},
+		{"uniform", DelayUniform, 2 * time.Second, time.Second, 0, time.Second, 3 * time.Second, false},
+		{"uniform without spread", DelayUniform, 2 * time.Second, 0, 0, 2 * time.Second, 2 * time.Second, false},
+		{"uniform above floor", DelayUniform, 2 * time.Second, time.Second, 1500 * time.Millisecond, 1500 * time.Millisecond, 3 * time.Second, true},
+		{"uniform below zero", DelayUniform, 100 * time.Millisecond, time.Second, 0, 0, 1100 * time.Millisecond, true},
+		{"uniform negative floor", DelayUniform, 100 * time.Millisecond, time.Second, -time.Second, 0, 1100 * time.Millisecond, true},
+		{"normal", DelayNormal, 2 * time.Second, 100 * time.Millisecond, time.Second, time.Second, 3 * time.Second, false},
+		{"normal below zero", DelayNormal, 0, time.Second, -time.Second, 0, 10 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := NewDelayFunc(tt.distribution, tt.base, tt.spread, tt.floor, rand.New(rand.NewSource(1)))
+			if err != nil {
+				t.Fatalf("NewDelayFunc() error = %v", err)
+			}
+
+			clamped := false
+			for i := 0; i < 1000; i++ {
+				d := next()
+				if d < tt.min || d > tt.max {
+					t.Fatalf("delay %v out of [%v, %v]", d, tt.min, tt.max)
+				}
+				clamped = clamped || d == tt.min
+			}
+			// Delays below the floor pile up at it instead of going past it
+			if tt.clamped && !clamped {
+				t.Errorf("no delay was clamped to %v", tt.min)
+			}
+		})
+	}
+}
+
+func TestNewDelayFuncIsReproducible(t *testing.T) {
+	first, _ := NewDelayFunc(DelayNormal, time.Second, 300*time.Millisecond, 0, rand.New(rand.NewSource(42)))
+	second, _ := NewDelayFunc(DelayNormal, time.Second, 300*time.Millisecond, 0, rand.New(rand.NewSource(42)))
+	for i := 0; i < 10; i++ {
+		if a, b := first(), second(); a != b {
+			t.Fatalf("delay %d = %v and %v with the same seed, want equal", i, a, b)
+		}
+	}
+}
+
+func TestNewDelayFuncUnknown(t *testing.T) {
+	if _, err := NewDelayFunc("poisson", time.Second, 0, 0, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("NewDelayFunc(\"poisson\") error = nil, want unknown distribution")
+	}
+}
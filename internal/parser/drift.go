@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// baselineKey is the store key of the recorded selector baseline
+const baselineKey = "selector_baseline"
+
+// RecordBaseline counts the selectors on the base URL and stores the counts as
+// the baseline that later cycles are compared with to detect markup changes
+func (p *AvitoParser) RecordBaseline() ([]SelectorReport, error) {
+	reports, err := p.CheckSelectors()
+	if err != nil {
+		return reports, err
+	}
+
+	data, err := json.Marshal(reports)
+	if err != nil {
+		return reports, fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+
+	// The baseline stays until it is recorded again
+	err = p.db.Set(p.namespacedKey(baselineKey), string(data), 0)
+	if err != nil {
+		return reports, fmt.Errorf("failed to save baseline: %w", err)
+	}
+	return reports, nil
+}
+
+// loadBaseline returns the recorded selector baseline, nil when there is none
+func (p *AvitoParser) loadBaseline(ctx context.Context) []SelectorReport {
+	value, err := p.dbFor(ctx).Get(p.namespacedKey(baselineKey))
+	if err != nil || value == "" {
+		return nil
+	}
+
+	var baseline []SelectorReport
+	err = json.Unmarshal([]byte(value), &baseline)
+	if err != nil {
+		log.Printf("Ignoring unreadable selector baseline: %v", err)
+		return nil
+	}
+	return baseline
+}
+
+// detectDrift compares live selector counts with the baseline. Drift is
+// suspected when the primary listing selector matched in the baseline but
+// matches nothing now while other selectors changed by more than half. A
+// primary count of zero with everything else unchanged is an empty search.
+func detectDrift(baseline, live []SelectorReport) (bool, string) {
+	liveCounts := make(map[string]int, len(live))
+	for _, report := range live {
+		liveCounts[report.Set+" "+report.Selector] = report.Matches
+	}
+
+	primary := "listing " + listingSelectors[0]
+	var changed []string
+	primaryGone := false
+	for _, report := range baseline {
+		key := report.Set + " " + report.Selector
+		count, ok := liveCounts[key]
+		if !ok {
+			continue
+		}
+
+		if key == primary {
+			primaryGone = report.Matches > 0 && count == 0
+			continue
+		}
+
+		diff := count - report.Matches
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff*2 > report.Matches && diff > 0 {
+			changed = append(changed, fmt.Sprintf("%s %d -> %d", key, report.Matches, count))
+		}
+	}
+
+	if !primaryGone || len(changed) == 0 {
+		return false, ""
+	}
+	return true, fmt.Sprintf("%s matches nothing, changed: %s", primary, strings.Join(changed, ", "))
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// filterListing returns why the listing should not be saved, or an empty string to keep it
+func (p *AvitoParser) filterListing(listing *models.Listing) string {
+	for _, required := range p.opts.RequireTags {
+		if !hasTag(listing.Tags, required) {
+			return fmt.Sprintf("missing required tag %q", required)
+		}
+	}
+
+	for _, excluded := range p.opts.ExcludeTags {
+		if hasTag(listing.Tags, excluded) {
+			return fmt.Sprintf("has excluded tag %q", excluded)
+		}
+	}
+
+	return ""
+}
+
+// hasTag checks if tags contain the tag, ignoring case
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByAge returns why the listing is too old to keep, or an empty string to keep it
+func (p *AvitoParser) filterByAge(listing *models.Listing, now time.Time) string {
+	if p.opts.MaxAge <= 0 {
+		return ""
+	}
+
+	if listing.PostedAt.IsZero() {
+		if p.opts.KeepUnknownAge {
+			return ""
+		}
+		return "posting date unknown"
+	}
+
+	age := now.Sub(listing.PostedAt)
+	if age > p.opts.MaxAge {
+		return fmt.Sprintf("posted %v ago, older than %v", age.Round(time.Minute), p.opts.MaxAge)
+	}
+	return ""
+}
+
+// filterByImages returns why a listing without photos is dropped, or an empty string to keep it
+func (p *AvitoParser) filterByImages(listing *models.Listing) string {
+	if p.opts.RequireImages && len(listing.Images) == 0 {
+		return "no photos"
+	}
+	return ""
+}
+
+// filterByLocation returns why the listing is outside the wanted areas, or an
+// empty string to keep it. Locations are matched as case-insensitive substrings.
+func (p *AvitoParser) filterByLocation(listing *models.Listing) string {
+	if len(p.opts.IncludeLocations) == 0 && len(p.opts.ExcludeLocations) == 0 {
+		return ""
+	}
+
+	if listing.Location == "" {
+		if p.opts.KeepUnknownLocation {
+			return ""
+		}
+		return "location unknown"
+	}
+
+	location := strings.ToLower(listing.Location)
+	for _, excluded := range p.opts.ExcludeLocations {
+		if strings.Contains(location, strings.ToLower(excluded)) {
+			return fmt.Sprintf("location %q matches excluded %q", listing.Location, excluded)
+		}
+	}
+
+	if len(p.opts.IncludeLocations) == 0 {
+		return ""
+	}
+	for _, included := range p.opts.IncludeLocations {
+		if strings.Contains(location, strings.ToLower(included)) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("location %q matches none of the included locations", listing.Location)
+}
+
+// refreshTTL keeps an already stored listing from expiring while it is still
+// listed but filtered out and therefore not saved again
+func (p *AvitoParser) refreshTTL(ctx context.Context, listing *models.Listing) {
+	expirer, ok := p.dbFor(ctx).(database.Expirer)
+	if !ok || listing.ID == "" {
+		return
+	}
+
+	err := expirer.Expire(listing.ID, listingTTL)
+	if err != nil {
+		log.Printf("Failed to refresh expiry of %s: %v", listing.ID, err)
+		return
+	}
+	p.seen.Extend(listing.ID, p.now().Add(listingTTL))
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+func TestFilterByAge(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		maxAge      time.Duration
+		keepUnknown bool
+		postedAt    time.Time
+		drop        bool
+	}{
+		{"filter disabled", 0, false, now.Add(-30 * 24 * time.Hour), false},
+		{"fresh", 24 * time.Hour, false, now.Add(-time.Hour), false},
+		{"exactly max age", 24 * time.Hour, false, now.Add(-24 * time.Hour), false},
+		{"too old", 24 * time.Hour, false, now.Add(-25 * time.Hour), true},
+		{"unknown date dropped", 24 * time.Hour, false, time.Time{}, true},
+		{"unknown date kept", 24 * time.Hour, true, time.Time{}, false},
+		{"unknown date without filter", 0, false, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &AvitoParser{opts: Options{MaxAge: tt.maxAge, KeepUnknownAge: tt.keepUnknown}}
+			reason := p.filterByAge(&models.Listing{ID: "listing_1", PostedAt: tt.postedAt}, now)
+			if (reason != "") != tt.drop {
+				t.Errorf("filterByAge() = %q, want dropped %v", reason, tt.drop)
+			}
+		})
+	}
+}
+
+func TestFilterByLocation(t *testing.T) {
+	tests := []struct {
+		name        string
+		include     []string
+		exclude     []string
+		keepUnknown bool
+		location    string
+		drop        bool
+	}{
+		{"no filter", nil, nil, false, "", false},
+		{"included district", []string{"Центральный"}, nil, false, "Челябинск, Центральный р-н", false},
+		{"included in another case", []string{"центральный"}, nil, false, "ЧЕЛЯБИНСК, ЦЕНТРАЛЬНЫЙ Р-Н", false},
+		{"none included", []string{"Центральный", "Калининский"}, nil, false, "Челябинск, Ленинский р-н", true},
+		{"excluded district", nil, []string{"Ленинский"}, false, "Челябинск, ленинский р-н", true},
+		{"not excluded", nil, []string{"Ленинский"}, false, "Челябинск, Курчатовский р-н", false},
+		{"excluded wins over included", []string{"Челябинск"}, []string{"Ленинский"}, false, "Челябинск, Ленинский р-н", true},
+		{"unknown dropped", []string{"Центральный"}, nil, false, "", true},
+		{"unknown kept", nil, []string{"Ленинский"}, true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &AvitoParser{opts: Options{
+				IncludeLocations:    tt.include,
+				ExcludeLocations:    tt.exclude,
+				KeepUnknownLocation: tt.keepUnknown,
+			}}
+			reason := p.filterByLocation(&models.Listing{ID: "listing_1", Location: tt.location})
+			if (reason != "") != tt.drop {
+				t.Errorf("filterByLocation(%q) = %q, want dropped %v", tt.location, reason, tt.drop)
+			}
+		})
+	}
+}
+
+func TestFilterByImages(t *testing.T) {
+	tests := []struct {
+		name    string
+		require bool
+		images  []string
+		drop    bool
+	}{
+		{"not required, no photos", false, nil, false},
+		{"not required, photos", false, []string{"https://00.img.avito.st/image/1.jpg"}, false},
+		{"required, no photos", true, nil, true},
+		{"required, empty list", true, []string{}, true},
+		{"required, photos", true, []string{"https://00.img.avito.st/image/1.jpg"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &AvitoParser{opts: Options{RequireImages: tt.require}}
+			reason := p.filterByImages(&models.Listing{ID: "listing_1", Images: tt.images})
+			if (reason != "") != tt.drop {
+				t.Errorf("filterByImages() = %q, want dropped %v", reason, tt.drop)
+			}
+		})
+	}
+}
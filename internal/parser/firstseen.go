@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"log"
+	"time"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// firstSeenTTL is how long the first-seen time of a listing outlives its last
+// sighting. It is much longer than listingTTL so a listing whose record lapsed
+// keeps its first-seen time when it shows up again.
+const firstSeenTTL = 30 * 24 * time.Hour
+
+// isNewListing tells whether a listing counts as new. Without a window a
+// listing is new when it wasn't stored, with one while it was first seen
+// within the window.
+func isNewListing(stored bool, firstSeen, now time.Time, window time.Duration) bool {
+	if window <= 0 {
+		return !stored
+	}
+	return now.Sub(firstSeen) < window
+}
+
+// parseFirstSeen reads a stored first-seen time, falling back to now
+func parseFirstSeen(value string, now time.Time) time.Time {
+	if value == "" {
+		return now
+	}
+
+	firstSeen, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return now
+	}
+	return firstSeen
+}
+
+// loadFirstSeen returns the recorded first-seen time of a listing that isn't stored
+func (p *AvitoParser) loadFirstSeen(db database.Store, id string, now time.Time) time.Time {
+	if p.opts.NewWindow <= 0 {
+		return now
+	}
+
+	value, err := db.Get(database.FirstSeenKey(id))
+	if err != nil {
+		return now // Never seen or unreadable
+	}
+	return parseFirstSeen(value, now)
+}
+
+// recordFirstSeen keeps the first-seen time of the listing beyond its own TTL
+func (p *AvitoParser) recordFirstSeen(db database.Store, listing *models.Listing) {
+	if p.opts.NewWindow <= 0 {
+		return
+	}
+
+	err := db.Set(database.FirstSeenKey(listing.ID), listing.CreatedAt.Format(time.RFC3339), firstSeenTTL)
+	if err != nil {
+		log.Printf("Failed to record first-seen time of %s: %v", listing.ID, err)
+	}
+}
+
+// loadFirstSeenMany returns the recorded first-seen times of listings that
+// aren't stored, keyed by listing id
+func (p *AvitoParser) loadFirstSeenMany(batch database.BatchStore, ids []string, now time.Time) map[string]time.Time {
+	firstSeen := make(map[string]time.Time, len(ids))
+	if p.opts.NewWindow <= 0 || len(ids) == 0 {
+		return firstSeen
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, database.FirstSeenKey(id))
+	}
+
+	values, err := batch.GetMany(keys)
+	if err != nil {
+		log.Printf("Failed to load first-seen times: %v", err)
+		return firstSeen
+	}
+
+	for _, id := range ids {
+		if value, ok := values[database.FirstSeenKey(id)]; ok {
+			firstSeen[id] = parseFirstSeen(value, now)
+		}
+	}
+	return firstSeen
+}
+
+// recordFirstSeenMany keeps the first-seen times of the listings beyond their own TTL
+func (p *AvitoParser) recordFirstSeenMany(batch database.BatchStore, listings []*models.Listing) {
+	if p.opts.NewWindow <= 0 || len(listings) == 0 {
+		return
+	}
+
+	values := make(map[string]string, len(listings))
+	for _, listing := range listings {
+		values[database.FirstSeenKey(listing.ID)] = listing.CreatedAt.Format(time.RFC3339)
+	}
+
+	failed, err := batch.SetMany(values, firstSeenTTL)
+	if err != nil {
+		log.Printf("Failed to record first-seen times: %v", err)
+		return
+	}
+	for key, saveErr := range failed {
+		log.Printf("Failed to record first-seen time %s: %v", key, saveErr)
+	}
+}
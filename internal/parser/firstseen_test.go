@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsNewListing(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		stored    bool
+		firstSeen time.Time
+		window    time.Duration
+		want      bool
+	}{
+		{"no window, not stored", false, now.Add(-48 * time.Hour), 0, true},
+		{"no window, stored", true, now, 0, false},
+		{"first seen now", false, now, time.Hour, true},
+		{"within the window", true, now.Add(-59 * time.Minute), time.Hour, true},
+		{"window ended", true, now.Add(-time.Hour), time.Hour, false},
+		{"record lapsed, seen long ago", false, now.Add(-48 * time.Hour), time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		if got := isNewListing(tt.stored, tt.firstSeen, now, tt.window); got != tt.want {
+			t.Errorf("%s: isNewListing() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseFirstSeen(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-04-30T08:15:00Z", time.Date(2024, 4, 30, 8, 15, 0, 0, time.UTC)},
+		{"2024-04-30T11:15:00+03:00", time.Date(2024, 4, 30, 8, 15, 0, 0, time.UTC)},
+		{"", now},
+		{"yesterday", now},
+	}
+
+	for _, tt := range tests {
+		if got := parseFirstSeen(tt.value, now); !got.Equal(tt.want) {
+			t.Errorf("parseFirstSeen(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
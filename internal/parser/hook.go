@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// cycleCommandTimeout bounds a run of the cycle command, which is killed after it
+const cycleCommandTimeout = time.Minute
+
+// cycleReport is the JSON the cycle command receives on stdin
+type cycleReport struct {
+	*CycleResult
+	Error string `json:"error,omitempty"`
+}
+
+// runCycleCommand runs CycleCommand with the cycle summary as JSON on stdin.
+// The command is started directly, without a shell, so nothing in it is
+// expanded. Failures are logged and never stop the parsing loop.
+func (p *AvitoParser) runCycleCommand(ctx context.Context, result *CycleResult, cycleErr error) {
+	if len(p.opts.CycleCommand) == 0 || result == nil {
+		return
+	}
+
+	report := cycleReport{CycleResult: result}
+	if cycleErr != nil {
+		report.Error = cycleErr.Error()
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		log.Printf("Failed to encode cycle summary: %v", err)
+		return
+	}
+
+	// Still report the last cycle while shutting down, within the timeout
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cycleCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.opts.CycleCommand[0], p.opts.CycleCommand[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Cycle command %s failed: %v: %s", p.opts.CycleCommand[0], err, strings.TrimSpace(string(output)))
+	}
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunCycleCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	tests := []struct {
+		name     string
+		result   *CycleResult
+		cycleErr error
+		want     map[string]any
+	}{
+		{
+			name:   "successful cycle",
+			result: &CycleResult{Pages: 2, Found: 50, New: 10, Duration: 90 * time.Second},
+			want:   map[string]any{"pages": 2.0, "found": 50.0, "new": 10.0, "duration_ns": float64(90 * time.Second)},
+		},
+		{
+			name:     "failed cycle",
+			result:   &CycleResult{Pages: 1, Blocked: 1},
+			cycleErr: errors.New("page 2 blocked"),
+			want:     map[string]any{"pages": 1.0, "blocked": 1.0, "error": "page 2 blocked"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "summary.json")
+			p := newTestParser(newMemStore(), Options{
+				CycleCommand: []string{"sh", "-c", `cat > "$1"`, "sh", out},
+			})
+			p.runCycleCommand(context.Background(), tt.result, tt.cycleErr)
+
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatalf("command output: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("stdin %q is not JSON: %v", data, err)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("%s = %v, want %v in %s", key, got[key], value, data)
+				}
+			}
+			if _, ok := got["error"]; ok != (tt.cycleErr != nil) {
+				t.Errorf("error present = %v, want %v in %s", ok, tt.cycleErr != nil, data)
+			}
+		})
+	}
+}
+
+func TestRunCycleCommandArgumentsNotExpanded(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	dir := t.TempDir()
+	injected := filepath.Join(dir, "injected")
+	out := filepath.Join(dir, "argument")
+	argument := "$(touch " + injected + "); touch " + injected
+	p := newTestParser(newMemStore(), Options{
+		CycleCommand: []string{"sh", "-c", `printf %s "$1" > "$2"`, "sh", argument, out},
+	})
+	p.runCycleCommand(context.Background(), &CycleResult{}, nil)
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("command output: %v", err)
+	}
+	if string(data) != argument {
+		t.Errorf("argument = %q, want it passed as is: %q", data, argument)
+	}
+	if _, err := os.Stat(injected); err == nil {
+		t.Error("an argument was run by a shell")
+	}
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"avito-parser/internal/models"
+
+	"github.com/go-rod/rod"
+)
+
+// imageDownloadInterval is the pause between image downloads
+const imageDownloadInterval = 500 * time.Millisecond
+
+// maxImageSize caps a downloaded image so a wrong URL can't fill the disk
+const maxImageSize = 10 << 20
+
+// imageAttributes hold an image URL, in order of preference. Lazy-loaded
+// images keep a placeholder in src until scrolled into view.
+var imageAttributes = []string{"src", "data-src", "srcset", "data-srcset"}
+
+// trackingImageMarkers in an image URL mean a tracking pixel rather than a photo
+var trackingImageMarkers = []string{"pixel", "1x1", "beacon"}
+
+// avitoOrigin resolves site-relative links of listing cards
+const avitoOrigin = "https://www.avito.ru"
+
+// absoluteURL resolves a protocol-relative or site-relative link of a card
+// against avitoOrigin, leaving absolute links as they are
+func absoluteURL(ref string) string {
+	switch {
+	case strings.HasPrefix(ref, "//"):
+		return "https:" + ref
+	case strings.HasPrefix(ref, "/"):
+		return avitoOrigin + ref
+	}
+	return ref
+}
+
+// imageURL returns the absolute https URL of an image attribute value, false
+// for data URIs, tracking pixels and anything that isn't a valid web URL
+func imageURL(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "data:") || strings.HasPrefix(value, "blob:") {
+		return "", false
+	}
+
+	parsedURL, err := url.Parse(absoluteURL(value))
+	if err != nil || parsedURL.Host == "" {
+		return "", false
+	}
+	switch parsedURL.Scheme {
+	case "https":
+	case "http":
+		parsedURL.Scheme = "https"
+	default:
+		return "", false
+	}
+
+	lowerPath := strings.ToLower(parsedURL.Path)
+	for _, marker := range trackingImageMarkers {
+		if strings.Contains(lowerPath, marker) {
+			return "", false
+		}
+	}
+	return parsedURL.String(), true
+}
+
+// isTrackingPixel reports whether an img element is sized 1x1 or smaller
+func isTrackingPixel(image *rod.Element) bool {
+	for _, attribute := range []string{"width", "height"} {
+		value, err := image.Attribute(attribute)
+		if err == nil && value != nil && (*value == "0" || *value == "1") {
+			return true
+		}
+	}
+	return false
+}
+
+// extractImages collects the image URLs of a card, lazy-loaded ones included
+func extractImages(element *rod.Element) []string {
+	images, err := element.Elements("img")
+	if err != nil {
+		return nil
+	}
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, image := range images {
+		if isTrackingPixel(image) {
+			continue
+		}
+
+		for _, attribute := range imageAttributes {
+			value, err := image.Attribute(attribute)
+			if err != nil || value == nil {
+				continue
+			}
+
+			raw := *value
+			if strings.HasSuffix(attribute, "srcset") {
+				raw = firstSrcsetURL(raw)
+			}
+			absolute, ok := imageURL(raw)
+			if !ok || seen[absolute] {
+				continue
+			}
+			seen[absolute] = true
+			urls = append(urls, absolute)
+			break
+		}
+	}
+	return urls
+}
+
+// firstSrcsetURL returns the first candidate URL of a srcset like "a.jpg 1x, b.jpg 2x"
+func firstSrcsetURL(srcset string) string {
+	candidate, _, _ := strings.Cut(srcset, ",")
+	fields := strings.Fields(candidate)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// downloadImages saves the first image of each listing to ImageDir as
+// <id>.jpg and records the path on the listing. Images already on disk are
+// not fetched again; failures are logged and leave the listing as it is.
+func (p *AvitoParser) downloadImages(ctx context.Context, listings []*models.Listing) {
+	if p.opts.ImageDir == "" {
+		return
+	}
+
+	err := os.MkdirAll(p.opts.ImageDir, 0o755)
+	if err != nil {
+		log.Printf("Failed to create image dir: %v", err)
+		return
+	}
+
+	client := &http.Client{Timeout: p.timeout}
+	for _, listing := range listings {
+		if len(listing.Images) == 0 || ctx.Err() != nil {
+			continue
+		}
+
+		path := filepath.Join(p.opts.ImageDir, listing.ID+".jpg")
+		if _, err := os.Stat(path); err == nil {
+			listing.ImagePath = path
+			continue
+		}
+
+		err := downloadImage(ctx, client, listing.Images[0], path)
+		if err != nil {
+			log.Printf("Failed to download image of %s: %v", listing.ID, err)
+			continue
+		}
+		listing.ImagePath = path
+
+		sleep(ctx, imageDownloadInterval)
+	}
+}
+
+// downloadImage fetches an image URL into path, refusing non-image responses
+func downloadImage(ctx context.Context, client *http.Client, imageURL, path string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("unexpected content type %q", contentType)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".image-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(resp.Body, maxImageSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if n > maxImageSize {
+		return fmt.Errorf("image larger than %d bytes", maxImageSize)
+	}
+	return os.Rename(tmp.Name(), path)
+}
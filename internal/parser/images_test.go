@@ -0,0 +1,73 @@
+package parser
+
+import "testing"
+
+func TestAbsoluteURL(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"//00.img.avito.st/image/1.jpg", "https://00.img.avito.st/image/1.jpg"},
+		{"/chelyabinsk/kvartiry/flat_1", "https://www.avito.ru/chelyabinsk/kvartiry/flat_1"},
+		{"https://www.avito.ru/chelyabinsk/kvartiry/flat_1", "https://www.avito.ru/chelyabinsk/kvartiry/flat_1"},
+		{"data:image/gif;base64,R0lGOD", "data:image/gif;base64,R0lGOD"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := absoluteURL(tt.ref); got != tt.want {
+			t.Errorf("absoluteURL(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestImageURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		ok    bool
+	}{
+		{"absolute https", "https://00.img.avito.st/image/1.jpg", "https://00.img.avito.st/image/1.jpg", true},
+		{"protocol-relative", "//00.img.avito.st/image/1.jpg", "https://00.img.avito.st/image/1.jpg", true},
+		{"site-relative", "/static/image/1.jpg", "https://www.avito.ru/static/image/1.jpg", true},
+		{"http upgraded", "http://00.img.avito.st/image/1.jpg", "https://00.img.avito.st/image/1.jpg", true},
+		{"surrounding spaces", "  https://00.img.avito.st/image/1.jpg\n", "https://00.img.avito.st/image/1.jpg", true},
+		{"data URI", "data:image/gif;base64,R0lGODlhAQABAAAAACw=", "", false},
+		{"blob", "blob:https://www.avito.ru/1234", "", false},
+		{"empty", "", "", false},
+		{"tracking pixel", "https://stats.avito.ru/pixel.gif", "", false},
+		{"1x1 image", "https://00.img.avito.st/image/1X1.png", "", false},
+		{"beacon", "//mc.yandex.ru/watch/beacon", "", false},
+		{"other scheme", "ftp://00.img.avito.st/image/1.jpg", "", false},
+		{"relative without slash", "image/1.jpg", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := imageURL(tt.value)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("imageURL(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFirstSrcsetURL(t *testing.T) {
+	tests := []struct {
+		srcset string
+		want   string
+	}{
+		{"//00.img.avito.st/image/1.jpg 1x, //00.img.avito.st/image/2.jpg 2x", "//00.img.avito.st/image/1.jpg"},
+		{"https://00.img.avito.st/image/1.jpg 208w,https://00.img.avito.st/image/2.jpg 416w", "https://00.img.avito.st/image/1.jpg"},
+		{"  /image/1.jpg  ", "/image/1.jpg"},
+		{"/image/1.jpg", "/image/1.jpg"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := firstSrcsetURL(tt.srcset); got != tt.want {
+			t.Errorf("firstSrcsetURL(%q) = %q, want %q", tt.srcset, got, tt.want)
+		}
+	}
+}
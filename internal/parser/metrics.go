@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// cycleMetrics accumulates the results of all cycles since start
+type cycleMetrics struct {
+	cycles       int64
+	failed       int64
+	found        int64
+	new          int64
+	updated      int64
+	filtered     int64
+	tooOld       int64
+	outOfArea    int64
+	noPhotos     int64
+	blocked      int64
+	lastDuration time.Duration
+	lastSuccess  time.Time
+}
+
+// add counts a finished cycle, failed when cycleErr is set
+func (m *cycleMetrics) add(result *CycleResult, cycleErr error, now time.Time) {
+	m.cycles++
+	if cycleErr != nil {
+		m.failed++
+	} else {
+		m.lastSuccess = now
+	}
+	if result == nil {
+		return
+	}
+
+	m.found += int64(result.Found)
+	m.new += int64(result.New)
+	m.updated += int64(result.Updated)
+	m.filtered += int64(result.Filtered)
+	m.tooOld += int64(result.TooOld)
+	m.outOfArea += int64(result.OutOfArea)
+	m.noPhotos += int64(result.NoPhotos)
+	m.blocked += int64(result.Blocked)
+	m.lastDuration = result.Duration
+}
+
+// metricDef describes one metric of the metrics file
+type metricDef struct {
+	name  string
+	help  string
+	kind  string
+	value func(m *cycleMetrics) float64
+}
+
+// metricDefs are the metrics written in Prometheus text exposition format
+var metricDefs = []metricDef{
+	{"avito_parser_cycles_total", "Parsing cycles run.", "counter", func(m *cycleMetrics) float64 { return float64(m.cycles) }},
+	{"avito_parser_cycles_failed_total", "Parsing cycles that ended with an error.", "counter", func(m *cycleMetrics) float64 { return float64(m.failed) }},
+	{"avito_parser_listings_found_total", "Listings found on search pages.", "counter", func(m *cycleMetrics) float64 { return float64(m.found) }},
+	{"avito_parser_listings_new_total", "New listings saved.", "counter", func(m *cycleMetrics) float64 { return float64(m.new) }},
+	{"avito_parser_listings_updated_total", "Stored listings updated.", "counter", func(m *cycleMetrics) float64 { return float64(m.updated) }},
+	{"avito_parser_listings_filtered_total", "Listings dropped by tag filters.", "counter", func(m *cycleMetrics) float64 { return float64(m.filtered) }},
+	{"avito_parser_listings_too_old_total", "Listings dropped for their age.", "counter", func(m *cycleMetrics) float64 { return float64(m.tooOld) }},
+	{"avito_parser_listings_out_of_area_total", "Listings dropped by location filters.", "counter", func(m *cycleMetrics) float64 { return float64(m.outOfArea) }},
+	{"avito_parser_listings_no_photos_total", "Listings dropped for having no photos.", "counter", func(m *cycleMetrics) float64 { return float64(m.noPhotos) }},
+	{"avito_parser_pages_blocked_total", "Page loads answered with a captcha or access-denied page.", "counter", func(m *cycleMetrics) float64 { return float64(m.blocked) }},
+	{"avito_parser_last_cycle_duration_seconds", "Duration of the last cycle.", "gauge", func(m *cycleMetrics) float64 { return m.lastDuration.Seconds() }},
+	{"avito_parser_last_success_timestamp_seconds", "Unix time the last successful cycle ended, 0 before the first.", "gauge", func(m *cycleMetrics) float64 {
+		if m.lastSuccess.IsZero() {
+			return 0
+		}
+		return float64(m.lastSuccess.Unix())
+	}},
+}
+
+// formatMetrics renders the metrics in Prometheus text exposition format
+func formatMetrics(m *cycleMetrics) []byte {
+	var buf bytes.Buffer
+	for _, def := range metricDefs {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", def.name, def.help)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", def.name, def.kind)
+		fmt.Fprintf(&buf, "%s %s\n", def.name, strconv.FormatFloat(def.value(m), 'f', -1, 64))
+	}
+	return buf.Bytes()
+}
+
+// writeMetrics counts the finished cycle and rewrites MetricsFile, e.g. for
+// node_exporter's textfile collector. The file is replaced by a rename so a
+// reader never sees it half written.
+func (p *AvitoParser) writeMetrics(result *CycleResult, cycleErr error) {
+	if p.opts.MetricsFile == "" {
+		return
+	}
+
+	p.metrics.add(result, cycleErr, p.now())
+	err := writeMetricsFile(p.opts.MetricsFile, formatMetrics(&p.metrics))
+	if err != nil {
+		log.Printf("Failed to write metrics file: %v", err)
+	}
+}
+
+// writeMetricsFile writes data under a temporary name next to path and renames it into place
+func writeMetricsFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".metrics-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "avito_parser.prom")
+	p := newTestParser(newMemStore(), Options{MetricsFile: path})
+
+	p.writeMetrics(&CycleResult{Found: 50, New: 10, Updated: 5, TooOld: 3, Blocked: 1, Duration: 90 * time.Second}, nil)
+	p.writeMetrics(&CycleResult{Found: 20, New: 2, NoPhotos: 4, Duration: 30 * time.Second}, errors.New("page 2 blocked"))
+	p.writeMetrics(nil, errors.New("browser is not running"))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("metrics file: %v", err)
+	}
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		lines[line] = true
+	}
+
+	for _, want := range []string{
+		"# HELP avito_parser_cycles_total Parsing cycles run.",
+		"# TYPE avito_parser_cycles_total counter",
+		"avito_parser_cycles_total 3",
+		"avito_parser_cycles_failed_total 2",
+		"avito_parser_listings_found_total 70",
+		"avito_parser_listings_new_total 12",
+		"avito_parser_listings_updated_total 5",
+		"avito_parser_listings_filtered_total 0",
+		"avito_parser_listings_too_old_total 3",
+		"avito_parser_listings_no_photos_total 4",
+		"avito_parser_pages_blocked_total 1",
+		"# TYPE avito_parser_last_cycle_duration_seconds gauge",
+		"avito_parser_last_cycle_duration_seconds 30",
+		"avito_parser_last_success_timestamp_seconds 1714564800",
+	} {
+		if !lines[want] {
+			t.Errorf("metrics file has no line %q:\n%s", want, data)
+		}
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("metrics dir holds %d files, want the temporary file gone", len(entries))
+	}
+}
+
+func TestWriteMetricsDisabled(t *testing.T) {
+	p := newTestParser(newMemStore(), Options{})
+	p.writeMetrics(&CycleResult{Found: 50}, nil)
+
+	if p.metrics.cycles != 0 {
+		t.Errorf("cycles = %d, want nothing counted without a metrics file", p.metrics.cycles)
+	}
+}
+
+func TestLastSuccessBeforeFirst(t *testing.T) {
+	var m cycleMetrics
+	m.add(nil, errors.New("browser is not running"), testNow)
+
+	if got := string(formatMetrics(&m)); !strings.Contains(got, "\navito_parser_last_success_timestamp_seconds 0\n") {
+		t.Errorf("formatMetrics() = %q, want last success 0 before the first success", got)
+	}
+}
@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"avito-parser/internal/models"
+)
+
+// metroTimePattern matches the walking time after a station, e.g. ", 10 мин.",
+// "6–10 мин." or "от 31 мин."
+var metroTimePattern = regexp.MustCompile(`(?i)[,\s\x{00a0}]*(?:(?:до|от)[\s\x{00a0}]+)?(\d+)(?:[\s\x{00a0}]*[–—-][\s\x{00a0}]*(\d+))?[\s\x{00a0}]*мин\.?`)
+
+// parseMetro splits a card's metro line like "м. Тракторозаводская, 10 мин."
+// into the station and the walking minutes. A range counts as its upper
+// bound. Both are empty when the card shows no metro.
+func parseMetro(text string) (station string, minutes int) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", 0
+	}
+
+	if match := metroTimePattern.FindStringSubmatchIndex(text); match != nil {
+		value := text[match[2]:match[3]]
+		if match[4] >= 0 {
+			value = text[match[4]:match[5]]
+		}
+		minutes, _ = strconv.Atoi(value)
+		text = text[:match[0]]
+	}
+
+	station = strings.TrimPrefix(strings.TrimSpace(text), "м.")
+	return strings.Trim(station, " \u00a0,"), minutes
+}
+
+// filterByMetro returns why the listing is away from the wanted stations, or
+// an empty string to keep it. Stations are matched as case-insensitive
+// substrings; listings without a metro follow KeepUnknownLocation.
+func (p *AvitoParser) filterByMetro(listing *models.Listing) string {
+	if len(p.opts.IncludeMetro) == 0 {
+		return ""
+	}
+
+	if listing.Metro == "" {
+		if p.opts.KeepUnknownLocation {
+			return ""
+		}
+		return "metro unknown"
+	}
+
+	station := strings.ToLower(listing.Metro)
+	for _, included := range p.opts.IncludeMetro {
+		if strings.Contains(station, strings.ToLower(included)) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("metro %q matches none of the included stations", listing.Metro)
+}
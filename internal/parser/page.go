@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// openPage creates a tab, applies the browser overrides and only then navigates,
+// so the very first request already carries them
+func (p *AvitoParser) openPage(pageURL string) (*rod.Page, error) {
+	browser, err := p.currentBrowser()
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.applyProfile(page)
+	if err != nil {
+		page.Close()
+		return nil, fmt.Errorf("failed to apply browser profile: %w", err)
+	}
+
+	err = p.applyLocale(page)
+	if err != nil {
+		page.Close()
+		return nil, fmt.Errorf("failed to apply locale: %w", err)
+	}
+
+	err = navigate(page, pageURL, p.searchReferer(pageURL))
+	if err != nil {
+		page.Close()
+		return nil, fmt.Errorf("failed to navigate: %w", err)
+	}
+
+	return page, nil
+}
+
+// navigate loads the URL in the page, sending referer as the Referer header when set
+func navigate(page *rod.Page, pageURL, referer string) error {
+	if referer == "" {
+		return page.Navigate(pageURL)
+	}
+
+	res, err := proto.PageNavigate{URL: pageURL, Referrer: referer}.Call(page)
+	if err != nil {
+		return err
+	}
+	if res.ErrorText != "" {
+		return errors.New(res.ErrorText)
+	}
+	return nil
+}
+
+// searchReferer returns the previous search page for page N > 1 of the search,
+// as if the visitor came through the pagination. Arriving at a later page with
+// no referer is a bot tell. Page 1 and other URLs get none.
+func (p *AvitoParser) searchReferer(pageURL string) string {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	pageNum, err := strconv.Atoi(parsedURL.Query().Get("p"))
+	if err != nil || pageNum <= 1 || pageURL != p.generatePageURL(pageNum) {
+		return ""
+	}
+	return p.generatePageURL(pageNum - 1)
+}
+
+// applyLocale sets the page locale and Accept-Language header
+func (p *AvitoParser) applyLocale(page *rod.Page) error {
+	if p.opts.Locale == "" {
+		return nil
+	}
+
+	err := proto.EmulationSetLocaleOverride{Locale: strings.ReplaceAll(p.opts.Locale, "-", "_")}.Call(page)
+	if err != nil {
+		return err
+	}
+
+	_, err = page.SetExtraHeaders([]string{"Accept-Language", acceptLanguage(p.opts.Locale)})
+	return err
+}
+
+// acceptLanguage builds an Accept-Language header value from a locale like ru-RU
+func acceptLanguage(locale string) string {
+	language, _, found := strings.Cut(locale, "-")
+	if !found {
+		return locale
+	}
+	return fmt.Sprintf("%s,%s;q=0.9", locale, language)
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"avito-parser/internal/util"
+
+	"github.com/go-rod/rod"
+)
+
+// Pagination limits. Without a result count the cycle stops after
+// fallbackMaxPages, with one after the expected last page but never beyond
+// hardMaxPages, which is as far as Avito paginates.
+const (
+	fallbackMaxPages = 50
+	hardMaxPages     = 100
+)
+
+// resultCountSelectors are tried in order to find the total result count on a search page
+var resultCountSelectors = []string{
+	"[data-marker='page-title/count']",
+	"[data-marker*='page-title'] [class*='count']",
+}
+
+// readResultCount returns the total number of results the search page shows, 0 when unknown
+func readResultCount(page *rod.Page) int {
+	for _, selector := range resultCountSelectors {
+		element, err := page.Sleeper(rod.NotFoundSleeper).Element(selector)
+		if err != nil || element == nil {
+			continue
+		}
+
+		text, err := element.Text()
+		if err != nil {
+			continue
+		}
+
+		if count, ok := parseResultCount(text); ok {
+			return count
+		}
+	}
+	return 0
+}
+
+// parseResultCount reads the number from a result count text like "1 234 объявления"
+func parseResultCount(text string) (int, bool) {
+	count, err := util.ParseRussianInt(text)
+	if err != nil || count <= 0 {
+		return 0, false
+	}
+	return int(count), true
+}
+
+// pageLimit is the last page worth loading for total results shown perPage at a
+// time, falling back to fallbackMaxPages when either is unknown
+func pageLimit(total, perPage int) int {
+	if total <= 0 || perPage <= 0 {
+		return fallbackMaxPages
+	}
+
+	pages := (total + perPage - 1) / perPage
+	if pages > hardMaxPages {
+		return hardMaxPages
+	}
+	return pages
+}
+
+// pageNumber reads the page number from the p parameter of a search URL, 1 when it has none
+func pageNumber(rawURL string) int {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return 1
+	}
+
+	page, err := strconv.Atoi(parsedURL.Query().Get("p"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+// redirectedPage reports whether a request for a page past the first ended up
+// on another page. Avito answers a page number beyond the last one with a
+// redirect, usually back to the first page, instead of a 404.
+func redirectedPage(requestedURL, finalURL string) bool {
+	requested := pageNumber(requestedURL)
+	return requested > 1 && finalURL != "" && pageNumber(finalURL) != requested
+}
+
+// emptyPastLastPage reports whether the empty-results block on a page means
+// its number is past the last page. On the first page it means the search
+// itself has no results.
+func emptyPastLastPage(pageURL string, noResults bool) bool {
+	return noResults && pageNumber(pageURL) > 1
+}
+
+// paginationEnd explains why pagination ends at a page without enough
+// listings, telling a page past the last one from an empty search and from a
+// page whose layout the selectors no longer match
+func paginationEnd(page int, probe *pageProbe) string {
+	switch {
+	case probe.RedirectedTo != "":
+		return fmt.Sprintf("Page %d is past the last page, Avito redirected to %s, ending pagination", page, probe.RedirectedTo)
+	case probe.OutOfRange:
+		return fmt.Sprintf("Page %d is past the last page, ending pagination", page)
+	case probe.NoResults:
+		return fmt.Sprintf("Page %d shows no results, ending pagination", page)
+	case probe.Count == 0:
+		return fmt.Sprintf("⚠️  WARNING: no listings and no empty-results marker on page %d, page layout may have changed", page)
+	}
+	return fmt.Sprintf("Found %d listings on page %d (less than minimum 3), ending pagination", probe.Count, page)
+}
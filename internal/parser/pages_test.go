@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+const searchURL = "https://www.avito.ru/chelyabinsk/kvartiry/sdam-ASgBAgICAUSSA8gQ"
+
+func TestPageNumber(t *testing.T) {
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{searchURL, 1},
+		{searchURL + "?p=3&localPriority=0", 3},
+		{searchURL + "?s=104&p=12", 12},
+		{searchURL + "?p=0", 1},
+		{searchURL + "?p=abc", 1},
+		{"%zz", 1},
+	}
+
+	for _, tt := range tests {
+		if got := pageNumber(tt.url); got != tt.want {
+			t.Errorf("pageNumber(%q) = %d, want %d", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestRedirectedPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		final     string
+		want      bool
+	}{
+		{"same page", searchURL + "?p=3&localPriority=0", searchURL + "?p=3&localPriority=0", false},
+		{"parameters reordered", searchURL + "?p=3&localPriority=0", searchURL + "?localPriority=0&p=3", false},
+		{"back to the first page", searchURL + "?p=40&localPriority=0", searchURL, true},
+		{"to the last page", searchURL + "?p=40&localPriority=0", searchURL + "?p=12&localPriority=0", true},
+		{"first page requested", searchURL, searchURL + "?s=104", false},
+		{"final URL unknown", searchURL + "?p=40", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := redirectedPage(tt.requested, tt.final); got != tt.want {
+			t.Errorf("%s: redirectedPage(%q, %q) = %v, want %v", tt.name, tt.requested, tt.final, got, tt.want)
+		}
+	}
+}
+
+func TestEmptyPastLastPage(t *testing.T) {
+	tests := []struct {
+		url       string
+		noResults bool
+		want      bool
+	}{
+		{searchURL + "?p=40", true, true},
+		{searchURL + "?p=2", true, true},
+		{searchURL, true, false},
+		{searchURL + "?p=40", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := emptyPastLastPage(tt.url, tt.noResults); got != tt.want {
+			t.Errorf("emptyPastLastPage(%q, %v) = %v, want %v", tt.url, tt.noResults, got, tt.want)
+		}
+	}
+}
+
+func TestPaginationEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		probe pageProbe
+		want  string
+	}{
+		{"redirect", pageProbe{OutOfRange: true, RedirectedTo: searchURL}, "past the last page, Avito redirected to " + searchURL},
+		{"empty state past the last page", pageProbe{NoResults: true, OutOfRange: true}, "past the last page, ending"},
+		{"empty search", pageProbe{NoResults: true}, "shows no results"},
+		{"layout break", pageProbe{}, "page layout may have changed"},
+		{"too few listings", pageProbe{Count: 2}, "Found 2 listings"},
+	}
+
+	for _, tt := range tests {
+		if got := paginationEnd(4, &tt.probe); !strings.Contains(got, tt.want) {
+			t.Errorf("%s: paginationEnd() = %q, want it to contain %q", tt.name, got, tt.want)
+		}
+	}
+}
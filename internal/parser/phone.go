@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"avito-parser/internal/util"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// phoneButtonSelectors match the "show phone" control on a listing page
+var phoneButtonSelectors = []string{
+	"[data-marker='item-phone-button/card']",
+	"button[data-marker*='phone-button']",
+	"[data-marker*='item-phone-button']",
+}
+
+// phoneNumberSelectors match the revealed number after the control is clicked
+var phoneNumberSelectors = []string{
+	"[data-marker='phone-popup/phone-number']",
+	"[data-marker*='phone-number']",
+	"a[href^='tel:']",
+}
+
+// minPhoneDigits is the least number of digits a revealed phone must have
+const minPhoneDigits = 10
+
+// revealPhone clicks the listing's phone control and reads the number it shows.
+// It is best effort: an empty string is returned when there is no control, the
+// number is drawn as an image or Avito asks to log in first.
+func (p *AvitoParser) revealPhone(page *rod.Page) string {
+	var button *rod.Element
+	for _, selector := range phoneButtonSelectors {
+		found, err := page.Sleeper(rod.NotFoundSleeper).Element(selector)
+		if err == nil && found != nil {
+			button = found
+			break
+		}
+	}
+	if button == nil {
+		return ""
+	}
+
+	err := button.Click(proto.InputMouseButtonLeft, 1)
+	if err != nil {
+		log.Printf("Failed to click phone button: %v", err)
+		return ""
+	}
+
+	body, err := page.Element("body")
+	if err != nil {
+		return ""
+	}
+
+	phone := p.extractText(body, phoneNumberSelectors, time.Now().Add(p.opts.ElementWait))
+	if !looksLikePhone(phone) {
+		return ""
+	}
+	return phone
+}
+
+// looksLikePhone checks that the text is a number rather than a login prompt or placeholder
+func looksLikePhone(text string) bool {
+	digits := 0
+	for _, r := range text {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	return digits >= minPhoneDigits && !strings.ContainsFunc(util.NormalizeText(text), unicode.IsLetter)
+}
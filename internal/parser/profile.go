@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// BrowserProfile bundles how pages present themselves to the site: user agent,
+// language and screen. Applying one profile keeps these consistent.
+type BrowserProfile struct {
+	Name              string
+	UserAgent         string
+	Platform          string
+	Locale            string
+	Width             int
+	Height            int
+	DeviceScaleFactor float64
+	Mobile            bool
+}
+
+// browserProfiles are the built-in profiles selectable with BROWSER_PROFILE
+var browserProfiles = map[string]BrowserProfile{
+	"desktop-chrome-ru": {
+		Name:              "desktop-chrome-ru",
+		UserAgent:         "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		Platform:          "Win32",
+		Locale:            "ru-RU",
+		Width:             1920,
+		Height:            1080,
+		DeviceScaleFactor: 1,
+	},
+	"android-chrome-ru": {
+		Name:              "android-chrome-ru",
+		UserAgent:         "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+		Platform:          "Linux armv8l",
+		Locale:            "ru-RU",
+		Width:             412,
+		Height:            915,
+		DeviceScaleFactor: 2.625,
+		Mobile:            true,
+	},
+}
+
+// BrowserProfileByName returns the built-in profile, nil for an empty name
+func BrowserProfileByName(name string) (*BrowserProfile, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	profile, ok := browserProfiles[name]
+	if !ok {
+		names := make([]string, 0, len(browserProfiles))
+		for known := range browserProfiles {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown browser profile %q, expected one of: %s", name, strings.Join(names, ", "))
+	}
+	return &profile, nil
+}
+
+// applyProfile sets the user agent and screen of the configured profile on the page
+func (p *AvitoParser) applyProfile(page *rod.Page) error {
+	profile := p.opts.Profile
+	if profile == nil {
+		return nil
+	}
+
+	err := page.SetUserAgent(&proto.NetworkSetUserAgentOverride{
+		UserAgent:      profile.UserAgent,
+		AcceptLanguage: acceptLanguage(profile.Locale),
+		Platform:       profile.Platform,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+		Width:             profile.Width,
+		Height:            profile.Height,
+		DeviceScaleFactor: profile.DeviceScaleFactor,
+		Mobile:            profile.Mobile,
+	})
+	if err != nil {
+		return err
+	}
+
+	if profile.Mobile {
+		return proto.EmulationSetTouchEmulationEnabled{Enabled: true}.Call(page)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"log"
+	"net/url"
+	"strings"
+)
+
+// proxyPool rotates through proxies, skipping ones that failed too often.
+// Chrome takes its proxy at launch, so switching means relaunching the browser.
+type proxyPool struct {
+	proxies     []string
+	failures    []int
+	maxFailures int
+	current     int
+}
+
+// newProxyPool returns a pool over the proxies, nil when the list is empty
+func newProxyPool(proxies []string, maxFailures int) *proxyPool {
+	if len(proxies) == 0 {
+		return nil
+	}
+	return &proxyPool{
+		proxies:     proxies,
+		failures:    make([]int, len(proxies)),
+		maxFailures: maxFailures,
+	}
+}
+
+// Current returns the proxy in use
+func (pp *proxyPool) Current() string {
+	return pp.proxies[pp.current]
+}
+
+// Fail records a failure of the current proxy
+func (pp *proxyPool) Fail() {
+	pp.failures[pp.current]++
+	if pp.dead(pp.current) {
+		log.Printf("Proxy %s failed %d times, taking it out of rotation", redactProxy(pp.Current()), pp.failures[pp.current])
+	}
+}
+
+// Next moves to the next live proxy after the current one.
+// It returns false when every proxy is dead.
+func (pp *proxyPool) Next() (string, bool) {
+	for i := 1; i <= len(pp.proxies); i++ {
+		candidate := (pp.current + i) % len(pp.proxies)
+		if !pp.dead(candidate) {
+			pp.current = candidate
+			return pp.proxies[candidate], true
+		}
+	}
+	return "", false
+}
+
+// dead reports whether the proxy at index i reached the failure limit
+func (pp *proxyPool) dead(i int) bool {
+	return pp.maxFailures > 0 && pp.failures[i] >= pp.maxFailures
+}
+
+// proxyServer splits a proxy URL into the server passed to Chrome and its credentials
+func proxyServer(proxy string) (server, username, password string) {
+	parsedURL, err := url.Parse(proxy)
+	if err != nil || parsedURL.Host == "" {
+		return proxy, "", ""
+	}
+
+	server = parsedURL.Scheme + "://" + parsedURL.Host
+	if parsedURL.User != nil {
+		username = parsedURL.User.Username()
+		password, _ = parsedURL.User.Password()
+	}
+	return server, username, password
+}
+
+// redactProxy hides proxy credentials in logs
+func redactProxy(proxy string) string {
+	server, username, _ := proxyServer(proxy)
+	if username == "" {
+		return server
+	}
+	return strings.Replace(server, "://", "://"+username+":***@", 1)
+}
+
+// rotateProxy marks the current proxy as failed and relaunches the browser
+// with the next live one. It returns false when there is nothing to switch to.
+func (p *AvitoParser) rotateProxy(failed bool) bool {
+	if p.proxies == nil {
+		return false
+	}
+
+	if failed {
+		p.proxies.Fail()
+	}
+	proxy, ok := p.proxies.Next()
+	if !ok {
+		log.Println("⚠️  WARNING: all proxies are dead, keeping the current browser")
+		return false
+	}
+
+	log.Printf("Switching to proxy %s", redactProxy(proxy))
+	if err := p.Close(); err != nil {
+		log.Printf("Error closing browser before proxy switch: %v", err)
+	}
+	if err := p.Start(); err != nil {
+		log.Printf("Failed to restart browser with proxy %s: %v", redactProxy(proxy), err)
+		return false
+	}
+	return true
+}
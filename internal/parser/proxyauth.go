@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// maxTrackedChallenges bounds how many challenged requests are remembered
+const maxTrackedChallenges = 1000
+
+// proxyAuth answers the auth challenges of a proxy with its credentials.
+// rod's HandleAuth answers a single challenge only, while a proxy may
+// challenge every connection the browser opens.
+type proxyAuth struct {
+	proxy    string
+	username string
+	password string
+	// challenged holds the requests already given the credentials
+	challenged map[proto.FetchRequestID]bool
+}
+
+// newProxyAuth creates the challenge handler of a proxy URL with credentials
+func newProxyAuth(proxy string) *proxyAuth {
+	_, username, password := proxyServer(proxy)
+	return &proxyAuth{
+		proxy:      proxy,
+		username:   username,
+		password:   password,
+		challenged: make(map[proto.FetchRequestID]bool),
+	}
+}
+
+// Respond decides how to answer an auth challenge. Site auth is left to the
+// browser. A second proxy challenge of the same request means the proxy
+// rejected the credentials, so it is cancelled instead of retried forever.
+func (a *proxyAuth) Respond(e *proto.FetchAuthRequired) *proto.FetchAuthChallengeResponse {
+	if e.AuthChallenge == nil || e.AuthChallenge.Source != proto.FetchAuthChallengeSourceProxy {
+		return &proto.FetchAuthChallengeResponse{
+			Response: proto.FetchAuthChallengeResponseResponseDefault,
+		}
+	}
+
+	if a.challenged[e.RequestID] {
+		delete(a.challenged, e.RequestID)
+		url := ""
+		if e.Request != nil {
+			url = e.Request.URL
+		}
+		log.Printf("🔑 Proxy authentication failed: %s rejected the credentials of %s (%s) for %s",
+			redactProxy(a.proxy), a.username, e.AuthChallenge.Scheme, url)
+		return &proto.FetchAuthChallengeResponse{
+			Response: proto.FetchAuthChallengeResponseResponseCancelAuth,
+		}
+	}
+
+	if len(a.challenged) >= maxTrackedChallenges {
+		a.challenged = make(map[proto.FetchRequestID]bool)
+	}
+	a.challenged[e.RequestID] = true
+	return &proto.FetchAuthChallengeResponse{
+		Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+		Username: a.username,
+		Password: a.password,
+	}
+}
+
+// handleProxyAuth answers every proxy challenge of the browser until it closes
+func handleProxyAuth(browser *rod.Browser, proxy string) error {
+	auth := newProxyAuth(proxy)
+	wait := browser.EachEvent(
+		func(e *proto.FetchRequestPaused) {
+			_ = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(browser)
+		},
+		func(e *proto.FetchAuthRequired) {
+			_ = proto.FetchContinueWithAuth{
+				RequestID:             e.RequestID,
+				AuthChallengeResponse: auth.Respond(e),
+			}.Call(browser)
+		},
+	)
+
+	err := proto.FetchEnable{HandleAuthRequests: true}.Call(browser)
+	if err != nil {
+		return fmt.Errorf("failed to enable proxy auth handling: %w", err)
+	}
+	go wait()
+	return nil
+}
@@ -0,0 +1,28 @@
+package parser
+
+import "avito-parser/internal/models"
+
+// Purge deletes the stored listings match selects, see database.Store.DeleteWhere.
+// They are also forgotten by the seen cache and dropped from the outage
+// buffer, so a purged listing that shows up again is saved as new instead of
+// being taken for a stored one. A running cycle is waited for.
+func (p *AvitoParser) Purge(match func(*models.Listing) bool) (int, error) {
+	p.cycleMu.Lock()
+	defer p.cycleMu.Unlock()
+
+	kept := p.pending[:0]
+	for _, listing := range p.pending {
+		if !match(listing) {
+			kept = append(kept, listing)
+		}
+	}
+	p.pending = kept
+
+	return p.db.DeleteWhere(func(listing *models.Listing) bool {
+		if !match(listing) {
+			return false
+		}
+		p.seen.Remove(listing.ID)
+		return true
+	})
+}
@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+func TestPurge(t *testing.T) {
+	store, err := database.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewAvitoParser(store, true, time.Second, "", 0, 0, Options{SeenCacheSize: 10})
+
+	now := time.Now()
+	cheap := &models.Listing{ID: "listing_1", PriceRUB: 20000}
+	expensive := &models.Listing{ID: "listing_2", PriceRUB: 90000}
+	for _, listing := range []*models.Listing{cheap, expensive} {
+		data, err := listing.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Set(listing.ID, string(data), 0); err != nil {
+			t.Fatal(err)
+		}
+		p.seen.Add(listing, now.Add(time.Hour))
+	}
+	buffered := &models.Listing{ID: "listing_3", PriceRUB: 80000}
+	p.pending = []*models.Listing{cheap, buffered}
+
+	deleted, err := p.Purge(func(listing *models.Listing) bool {
+		return listing.PriceRUB > 50000
+	})
+	if err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Purge() = %d, want 1", deleted)
+	}
+
+	if exists, _ := store.Exists(expensive.ID); exists {
+		t.Error("purged listing is still stored")
+	}
+	if _, ok := p.seen.Get(expensive.ID, now); ok {
+		t.Error("purged listing is still in the seen cache")
+	}
+	if _, ok := p.seen.Get(cheap.ID, now); !ok {
+		t.Error("kept listing was dropped from the seen cache")
+	}
+	if len(p.pending) != 1 || p.pending[0].ID != cheap.ID {
+		t.Errorf("buffered listings = %v, want only %s", p.pending, cheap.ID)
+	}
+}
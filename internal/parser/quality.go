@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"avito-parser/internal/models"
+)
+
+// priceNotSpecified is stored when a card has no usable price
+const priceNotSpecified = "Price not specified"
+
+// archivedMarkers are card phrases shown for listings removed from publication
+var archivedMarkers = []string{
+	"снято с публикации",
+	"объявление снято",
+	"в архиве",
+}
+
+// RejectedError is returned for listings that look like extraction garbage
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "listing rejected: " + e.Reason
+}
+
+// checkQuality rejects listings whose fields are unlikely to come from a real card
+func (p *AvitoParser) checkQuality(listing *models.Listing) error {
+	title := strings.TrimSpace(listing.Title)
+	if len([]rune(title)) < p.opts.MinTitleLength {
+		return &RejectedError{Reason: fmt.Sprintf("title %q is shorter than %d characters", title, p.opts.MinTitleLength)}
+	}
+
+	for _, rejected := range p.opts.RejectTitles {
+		if strings.EqualFold(title, rejected) {
+			return &RejectedError{Reason: fmt.Sprintf("title %q is a known non-title label", title)}
+		}
+	}
+
+	if listing.URL != "" && !isAvitoURL(listing.URL) {
+		return &RejectedError{Reason: fmt.Sprintf("URL %q is not on avito.ru", listing.URL)}
+	}
+
+	if p.opts.RequirePriceDigits && listing.Price != priceNotSpecified && !strings.ContainsFunc(listing.Price, unicode.IsDigit) {
+		return &RejectedError{Reason: fmt.Sprintf("price %q has no digits", listing.Price)}
+	}
+
+	return nil
+}
+
+// checkPriceBounds replaces a price outside the plausible range, like a floor
+// number picked up by a wrong selector, with priceNotSpecified. The bounds are
+// in rubles, so a foreign price is checked after conversion and not at all
+// without a rate. The scraped text is kept in PriceRaw.
+func (p *AvitoParser) checkPriceBounds(listing *models.Listing) {
+	value, ok := listing.RublePrice()
+	if !ok {
+		return
+	}
+
+	if (p.opts.MinPrice > 0 && value < p.opts.MinPrice) || (p.opts.MaxPrice > 0 && value > p.opts.MaxPrice) {
+		log.Printf("Implausible price %q for %s, storing as not specified", listing.Price, listing.Title)
+		listing.PriceRaw = listing.Price
+		listing.Price = priceNotSpecified
+		listing.Currency = ""
+		listing.PriceRUB = 0
+	}
+}
+
+// isAvitoURL checks that the URL points to avito.ru or one of its subdomains
+func isAvitoURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(parsedURL.Hostname())
+	return host == "avito.ru" || strings.HasSuffix(host, ".avito.ru")
+}
+
+// isArchived checks the card text for a removed-from-publication marker
+func isArchived(cardText string) bool {
+	text := strings.ToLower(cardText)
+	for _, marker := range archivedMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"testing"
+
+	"avito-parser/internal/models"
+)
+
+func TestCheckPriceBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int64
+		price    string
+		currency string
+		priceRUB int64
+		want     string
+		wantRaw  string
+	}{
+		{"in range", 1000, 10000000, "40 000 ₽", "", 0, "40 000 ₽", ""},
+		{"below floor", 1000, 10000000, "24 ₽", "", 0, priceNotSpecified, "24 ₽"},
+		{"above ceiling", 1000, 10000000, "99 000 000 ₽", "", 0, priceNotSpecified, "99 000 000 ₽"},
+		{"at the bounds", 24, 24, "24 ₽", "", 0, "24 ₽", ""},
+		{"bounds disabled", 0, 0, "24 ₽", "", 0, "24 ₽", ""},
+		{"no price", 1000, 10000000, priceNotSpecified, "", 0, priceNotSpecified, ""},
+		{"converted foreign price", 1000, 10000000, "$500", "USD", 45000, "$500", ""},
+		{"converted foreign price too low", 1000, 10000000, "$5", "USD", 450, priceNotSpecified, "$5"},
+		{"foreign price without a rate", 1000, 10000000, "$5", "USD", 0, "$5", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &AvitoParser{opts: Options{MinPrice: tt.min, MaxPrice: tt.max}}
+			listing := &models.Listing{Title: "Flat", Price: tt.price, Currency: tt.currency, PriceRUB: tt.priceRUB}
+			p.checkPriceBounds(listing)
+
+			if listing.Price != tt.want {
+				t.Errorf("Price = %q, want %q", listing.Price, tt.want)
+			}
+			if listing.PriceRaw != tt.wantRaw {
+				t.Errorf("PriceRaw = %q, want %q", listing.PriceRaw, tt.wantRaw)
+			}
+			if tt.want == priceNotSpecified && (listing.Currency != "" || listing.PriceRUB != 0) {
+				t.Errorf("Currency, PriceRUB = %q, %d, want them cleared", listing.Currency, listing.PriceRUB)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"net/url"
+	"strings"
+)
+
+// setQueryParam sets key to value in a raw query string without re-encoding
+// or reordering the other parameters, so opaque values like Avito's context
+// blob stay byte for byte. A missing key is appended. With overwrite the first
+// occurrence is replaced and repeats are dropped, without it the query is
+// left as it is when the key is present.
+func setQueryParam(rawQuery, key, value string, overwrite bool) string {
+	param := url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	if rawQuery == "" {
+		return param
+	}
+
+	parts := strings.Split(rawQuery, "&")
+	merged := make([]string, 0, len(parts)+1)
+	found := false
+	for _, part := range parts {
+		name, _, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+
+		switch {
+		case name != key || !overwrite:
+			merged = append(merged, part)
+		case !found:
+			merged = append(merged, param)
+		}
+		found = found || name == key
+	}
+
+	if !found {
+		merged = append(merged, param)
+	}
+	return strings.Join(merged, "&")
+}
@@ -0,0 +1,35 @@
+package parser
+
+import "testing"
+
+func TestSetQueryParam(t *testing.T) {
+	tests := []struct {
+		name      string
+		rawQuery  string
+		key       string
+		value     string
+		overwrite bool
+		want      string
+	}{
+		{"empty query", "", "p", "2", true, "p=2"},
+		{"missing key is appended", "q=flat", "p", "2", true, "q=flat&p=2"},
+		{"replaced in place", "p=1&q=flat", "p", "2", true, "p=2&q=flat"},
+		{"repeats dropped", "p=1&q=flat&p=3", "p", "2", true, "p=2&q=flat"},
+		{"kept without overwrite", "p=1&q=flat", "p", "2", false, "p=1&q=flat"},
+		{"appended without overwrite", "q=flat", "p", "2", false, "q=flat&p=2"},
+		{"opaque values untouched", "context=H4sIAAAA%2Bfoo%3D%3D&p=1", "p", "2", true, "context=H4sIAAAA%2Bfoo%3D%3D&p=2"},
+		{"escaped key matched", "s%5Bsort%5D=1", "s[sort]", "104", true, "s%5Bsort%5D=104"},
+		{"value escaped", "q=flat", "q", "2-к квартира", true, "q=2-%D0%BA+%D0%BA%D0%B2%D0%B0%D1%80%D1%82%D0%B8%D1%80%D0%B0"},
+		{"key without value", "p&q=flat", "p", "2", true, "p=2&q=flat"},
+		{"prefix not matched", "pp=1", "p", "2", true, "pp=1&p=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := setQueryParam(tt.rawQuery, tt.key, tt.value, tt.overwrite)
+			if got != tt.want {
+				t.Errorf("setQueryParam(%q, %q, %q, %v) = %q, want %q", tt.rawQuery, tt.key, tt.value, tt.overwrite, got, tt.want)
+			}
+		})
+	}
+}
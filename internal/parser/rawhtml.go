@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// rawHTMLTimeFormat is the timestamp in raw HTML file names, sortable as text
+const rawHTMLTimeFormat = "20060102T150405.000"
+
+// saveRawHTML writes the loaded page to RawHTMLDir/page-<time>-<url hash>.html
+// for offline reprocessing with -replay, then prunes old captures. The page
+// URL is kept in a leading comment.
+func (p *AvitoParser) saveRawHTML(page *rod.Page, pageURL string) {
+	if p.opts.RawHTMLDir == "" {
+		return
+	}
+
+	html, err := page.HTML()
+	if err != nil {
+		log.Printf("Failed to read raw HTML of %s: %v", pageURL, err)
+		return
+	}
+
+	err = os.MkdirAll(p.opts.RawHTMLDir, 0o755)
+	if err != nil {
+		log.Printf("Failed to create raw HTML dir: %v", err)
+		return
+	}
+
+	now := p.now()
+	sum := sha1.Sum([]byte(pageURL))
+	name := fmt.Sprintf("page-%s-%s.html", now.Format(rawHTMLTimeFormat), hex.EncodeToString(sum[:4]))
+	content := fmt.Sprintf("<!-- %s -->\n%s", strings.ReplaceAll(pageURL, "--", "%2D%2D"), html)
+
+	err = os.WriteFile(filepath.Join(p.opts.RawHTMLDir, name), []byte(content), 0o644)
+	if err != nil {
+		log.Printf("Failed to save raw HTML of %s: %v", pageURL, err)
+		return
+	}
+
+	removed, err := pruneRawHTML(p.opts.RawHTMLDir, p.opts.RawHTMLMaxFiles, p.opts.RawHTMLRetention, now)
+	if err != nil {
+		log.Printf("Failed to prune raw HTML: %v", err)
+	}
+	if removed > 0 {
+		log.Printf("Pruned %d raw HTML captures", removed)
+	}
+}
+
+// pruneRawHTML keeps at most maxFiles captures in dir, none older than
+// retention, and returns how many were removed. A zero limit is not applied.
+func pruneRawHTML(dir string, maxFiles int, retention time.Duration, now time.Time) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "page-*.html"))
+	if err != nil {
+		return 0, err
+	}
+
+	// Names start with the capture time, so the oldest sort first
+	sort.Strings(paths)
+
+	removed := 0
+	for i, path := range paths {
+		expired := false
+		if retention > 0 {
+			stamp := strings.TrimPrefix(filepath.Base(path), "page-")
+			if len(stamp) >= len(rawHTMLTimeFormat) {
+				takenAt, err := time.ParseInLocation(rawHTMLTimeFormat, stamp[:len(rawHTMLTimeFormat)], now.Location())
+				expired = err == nil && now.Sub(takenAt) > retention
+			}
+		}
+		excess := maxFiles > 0 && len(paths)-i > maxFiles
+
+		if !expired && !excess {
+			continue
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,215 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"avito-parser/internal/export"
+	"avito-parser/internal/models"
+)
+
+// removedPageMarkers are phrases of the page Avito shows for a listing that no
+// longer exists
+var removedPageMarkers = []string{
+	"объявление удалено",
+	"объявление не найдено",
+	"такой страницы нет",
+	"страница не найдена",
+}
+
+// detailTitleSelectors match the title on a listing's own page
+var detailTitleSelectors = []string{
+	"[data-marker='item-view/title-info']",
+	"h1",
+}
+
+// detailPriceSelectors match the price on a listing's own page
+var detailPriceSelectors = []string{
+	"[data-marker='item-view/item-price']",
+	"[itemprop='price']",
+}
+
+// listingDetail is what a listing's own page currently shows
+type listingDetail struct {
+	Title    string
+	Price    string
+	Archived bool
+	Phone    string
+	// Removed is set when the page says the listing is gone
+	Removed bool
+}
+
+// errUnreachable marks a listing page that loaded without showing a listing
+var errUnreachable = errors.New("listing page unreachable")
+
+// detailFetcher reads the current state of a listing page
+type detailFetcher func(ctx context.Context, listingURL string) (*listingDetail, error)
+
+// RefreshAll re-fetches the page of every stored listing and updates its
+// price, status and last-seen time. Unreachable listings, whose page says they
+// are gone, fails to load or shows no listing, are marked as removed; the
+// search cycle makes them active again if they come back. A blocked page or a
+// browser failure stops the refresh without touching the listings left, as it
+// says nothing about them. Stored listings are read page by page when the
+// store can scan, and fetched one by one with the page delay between them.
+func (p *AvitoParser) RefreshAll(ctx context.Context) error {
+	p.cycleMu.Lock()
+	defer p.cycleMu.Unlock()
+
+	log.Printf("Refreshing stored listings...")
+
+	db := p.dbFor(ctx)
+	refreshed, removed := 0, 0
+	err := export.Each(db, func(listing *models.Listing) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if listing.URL == "" || listing.Status == models.StatusRemoved {
+			return nil
+		}
+
+		detail, err := p.details(ctx, listing.URL)
+		if errors.Is(err, errUnreachable) {
+			log.Printf("Listing %s is unreachable, marking it as removed: %v", listing.ID, err)
+			detail = &listingDetail{Removed: true}
+		} else if err != nil {
+			return fmt.Errorf("failed to fetch the page of %s: %w", listing.ID, err)
+		}
+
+		mergeDetail(listing, detail, p.now(), p.opts.FXRates)
+		if listing.Status == models.StatusRemoved {
+			removed++
+		} else {
+			refreshed++
+		}
+
+		data, err := p.project(listing).ToJSON()
+		if err != nil {
+			log.Printf("Failed to convert listing %s to JSON: %v", listing.ID, err)
+			return nil
+		}
+
+		err = db.Set(listing.ID, string(data), listingTTL)
+		if err != nil {
+			log.Printf("Failed to save refreshed listing %s: %v", listing.ID, err)
+		} else {
+			p.seen.Add(p.project(listing), p.now().Add(listingTTL))
+		}
+
+		if delay := p.opts.NextDelay(); delay > 0 {
+			return sleep(ctx, delay)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Refresh stopped: %d refreshed, %d removed: %v", refreshed, removed, err)
+		return err
+	}
+
+	log.Printf("Refresh finished: %d refreshed, %d removed", refreshed, removed)
+	return nil
+}
+
+// mergeDetail applies a freshly fetched detail page to a stored listing.
+// Rates convert a changed price to rubles. A nil detail changes nothing.
+func mergeDetail(listing *models.Listing, detail *listingDetail, now time.Time, rates map[string]float64) {
+	if detail == nil {
+		return
+	}
+
+	listing.UpdatedAt = now
+	if detail.Removed {
+		listing.Status = models.StatusRemoved
+		return
+	}
+
+	listing.LastSeenAt = now
+	if detail.Price != "" {
+		listing.Price = detail.Price
+		listing.Currency = models.DetectCurrency(listing.Price)
+		listing.PriceRUB = listing.ComputePriceRUB(rates)
+		listing.PricePerSqm = listing.ComputePricePerSqm()
+	}
+	// Keep a previously revealed phone when it can't be read this time
+	if detail.Phone != "" {
+		listing.Phone = detail.Phone
+	}
+
+	if detail.Archived {
+		listing.Status = models.StatusArchived
+	} else {
+		listing.Status = models.StatusActive
+	}
+}
+
+// fetchDetail opens a listing page and reads its current state. It returns
+// errBlocked for a captcha or access-denied page, errUnreachable for a page
+// that fails to load or has no title, and a detail with Removed set when the
+// page says the listing is gone.
+func (p *AvitoParser) fetchDetail(ctx context.Context, listingURL string) (*listingDetail, error) {
+	page, err := p.openPage(listingURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page: %w", err)
+	}
+	defer page.Close()
+
+	err = page.Context(ctx).WaitLoad()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to wait for page load: %v", errUnreachable, err)
+	}
+
+	// Wait for dynamic content, a removed listing has no title and takes the whole wait
+	if err := waitForAny(ctx, page, detailTitleSelectors, p.opts.ContentWait); err != nil {
+		return nil, err
+	}
+
+	if p.isBlocked(page) {
+		return nil, errBlocked
+	}
+
+	body, err := page.Element("body")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find page body: %w", err)
+	}
+
+	bodyText, err := body.Text()
+	if err == nil && isRemovedPage(bodyText) {
+		return &listingDetail{Removed: true}, nil
+	}
+
+	deadline := time.Now().Add(p.opts.ElementWait)
+	detail := &listingDetail{
+		Title: p.extractText(body, detailTitleSelectors, deadline),
+		Price: p.extractText(body, detailPriceSelectors, deadline),
+	}
+
+	if detail.Title == "" {
+		return nil, fmt.Errorf("%w: listing page has no title", errUnreachable)
+	}
+
+	detail.Archived = isArchived(bodyText)
+
+	if p.opts.RevealPhone && !detail.Archived {
+		detail.Phone = p.revealPhone(page)
+	}
+
+	return detail, nil
+}
+
+// isRemovedPage checks the page text for the marker of a listing that no longer exists
+func isRemovedPage(pageText string) bool {
+	text := strings.ToLower(pageText)
+	for _, marker := range removedPageMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"avito-parser/internal/clock"
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+func TestMergeDetail(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	seen := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		detail   *listingDetail
+		status   string
+		price    string
+		phone    string
+		updated  time.Time
+		lastSeen time.Time
+	}{
+		{"no detail", nil, models.StatusActive, "40 000 ₽", "+7 900 000-00-00", time.Time{}, seen},
+		{"price changed", &listingDetail{Title: "Flat", Price: "45 000 ₽"}, models.StatusActive, "45 000 ₽", "+7 900 000-00-00", now, now},
+		{"price unreadable", &listingDetail{Title: "Flat"}, models.StatusActive, "40 000 ₽", "+7 900 000-00-00", now, now},
+		{"new phone", &listingDetail{Title: "Flat", Phone: "+7 911 111-11-11"}, models.StatusActive, "40 000 ₽", "+7 911 111-11-11", now, now},
+		{"archived", &listingDetail{Title: "Flat", Archived: true}, models.StatusArchived, "40 000 ₽", "+7 900 000-00-00", now, now},
+		{"removed", &listingDetail{Removed: true}, models.StatusRemoved, "40 000 ₽", "+7 900 000-00-00", now, seen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := &models.Listing{
+				ID:         "listing_1",
+				Price:      "40 000 ₽",
+				Phone:      "+7 900 000-00-00",
+				Status:     models.StatusActive,
+				LastSeenAt: seen,
+			}
+			mergeDetail(listing, tt.detail, now, nil)
+
+			if listing.Status != tt.status {
+				t.Errorf("Status = %q, want %q", listing.Status, tt.status)
+			}
+			if listing.Price != tt.price {
+				t.Errorf("Price = %q, want %q", listing.Price, tt.price)
+			}
+			if listing.Phone != tt.phone {
+				t.Errorf("Phone = %q, want %q", listing.Phone, tt.phone)
+			}
+			if !listing.UpdatedAt.Equal(tt.updated) {
+				t.Errorf("UpdatedAt = %v, want %v", listing.UpdatedAt, tt.updated)
+			}
+			if !listing.LastSeenAt.Equal(tt.lastSeen) {
+				t.Errorf("LastSeenAt = %v, want %v", listing.LastSeenAt, tt.lastSeen)
+			}
+		})
+	}
+}
+
+// scanOnlyStore fails All so a test notices the whole dataset being loaded
+type scanOnlyStore struct {
+	*database.FileStore
+}
+
+func (s scanOnlyStore) All() ([]string, error) {
+	return nil, errors.New("All() called on a store that can scan")
+}
+
+// fakePages serves listing details by URL without a browser and records the
+// URLs fetched
+type fakePages struct {
+	details map[string]*listingDetail
+	errs    map[string]error
+	fetched []string
+}
+
+func (f *fakePages) fetch(ctx context.Context, listingURL string) (*listingDetail, error) {
+	f.fetched = append(f.fetched, listingURL)
+	if err := f.errs[listingURL]; err != nil {
+		return nil, err
+	}
+	return f.details[listingURL], nil
+}
+
+// newRefreshParser stores the listings and returns a parser reading their
+// pages from pages
+func newRefreshParser(t *testing.T, pages *fakePages, listings ...*models.Listing) (*AvitoParser, database.Store) {
+	t.Helper()
+	files, err := database.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := scanOnlyStore{files}
+	for _, listing := range listings {
+		data, err := listing.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Set(listing.ID, string(data), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := NewAvitoParser(store, true, time.Second, "", 0, 0, Options{
+		Clock: clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)),
+	})
+	p.details = pages.fetch
+	return p, store
+}
+
+func storedStatus(t *testing.T, store database.Store, id string) string {
+	t.Helper()
+	listing, err := loadListing(store, id)
+	if err != nil || listing == nil {
+		t.Fatalf("loadListing(%q) = %v, %v", id, listing, err)
+	}
+	return listing.Status
+}
+
+func TestRefreshAll(t *testing.T) {
+	var listings []*models.Listing
+	for i, status := range []string{models.StatusActive, models.StatusActive, models.StatusActive, models.StatusActive, models.StatusRemoved} {
+		listings = append(listings, &models.Listing{
+			ID:     fmt.Sprintf("listing_%d", i+1),
+			URL:    fmt.Sprintf("https://www.avito.ru/%d", i+1),
+			Price:  "40 000 ₽",
+			Status: status,
+		})
+	}
+	pages := &fakePages{
+		details: map[string]*listingDetail{
+			"https://www.avito.ru/1": {Title: "Flat", Price: "45 000 ₽"},
+			"https://www.avito.ru/2": {Title: "Flat", Archived: true},
+			"https://www.avito.ru/3": {Removed: true},
+		},
+		errs: map[string]error{
+			"https://www.avito.ru/4": fmt.Errorf("%w: listing page has no title", errUnreachable),
+		},
+	}
+	p, store := newRefreshParser(t, pages, listings...)
+
+	if err := p.RefreshAll(context.Background()); err != nil {
+		t.Fatalf("RefreshAll() error = %v", err)
+	}
+
+	want := map[string]string{
+		"listing_1": models.StatusActive,
+		"listing_2": models.StatusArchived,
+		"listing_3": models.StatusRemoved,
+		"listing_4": models.StatusRemoved,
+		"listing_5": models.StatusRemoved,
+	}
+	for id, status := range want {
+		if got := storedStatus(t, store, id); got != status {
+			t.Errorf("status of %s = %q, want %q", id, got, status)
+		}
+	}
+	if listing, _ := loadListing(store, "listing_1"); listing.Price != "45 000 ₽" {
+		t.Errorf("price of listing_1 = %q, want the refreshed 45 000 ₽", listing.Price)
+	}
+	if len(pages.fetched) != 4 {
+		t.Errorf("fetched %v, want the 4 listings not removed yet", pages.fetched)
+	}
+}
+
+func TestRefreshAllStops(t *testing.T) {
+	browserDown := errors.New("browser is not running")
+
+	tests := []struct {
+		name      string
+		fetchErr  error
+		cancelled bool
+		want      error
+	}{
+		{"blocked", errBlocked, false, errBlocked},
+		{"browser down", browserDown, false, browserDown},
+		{"cancelled", nil, true, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := &models.Listing{ID: "listing_1", URL: "https://www.avito.ru/1", Status: models.StatusActive}
+			pages := &fakePages{
+				details: map[string]*listingDetail{listing.URL: {Removed: true}},
+				errs:    map[string]error{listing.URL: tt.fetchErr},
+			}
+			p, store := newRefreshParser(t, pages, listing)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelled {
+				cancel()
+			}
+
+			if err := p.RefreshAll(ctx); !errors.Is(err, tt.want) {
+				t.Fatalf("RefreshAll() error = %v, want %v", err, tt.want)
+			}
+			if got := storedStatus(t, store, listing.ID); got != models.StatusActive {
+				t.Errorf("status = %q, want the listing left as stored", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"avito-parser/internal/models"
+)
+
+// Replay runs the extraction on saved search pages instead of live ones.
+// The path is an HTML file or a directory whose .html files are replayed in
+// name order. Listings are returned without being saved.
+func (p *AvitoParser) Replay(path string) ([]*models.Listing, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	}
+
+	var listings []*models.Listing
+	for _, file := range files {
+		parsed, err := p.replayFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay %s: %w", file, err)
+		}
+		listings = append(listings, parsed...)
+	}
+	return listings, nil
+}
+
+// replayFile loads a saved page into a blank tab and parses its cards
+func (p *AvitoParser) replayFile(file string) ([]*models.Listing, error) {
+	html, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := p.openPage("about:blank")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page: %w", err)
+	}
+	defer page.Close()
+
+	err = page.SetDocumentContent(string(html))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load HTML: %w", err)
+	}
+
+	listingElements, _, err := p.findListingElements(page)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseElements(context.Background(), listingElements)
+}
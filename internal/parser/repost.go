@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"log"
+	"net/url"
+	"os"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// imageHash identifies the first photo of a listing: the hash of the
+// downloaded file when there is one, otherwise of its URL without the query.
+// It is empty for listings without photos.
+func imageHash(listing *models.Listing) string {
+	if listing.ImagePath != "" {
+		data, err := os.ReadFile(listing.ImagePath)
+		if err == nil {
+			sum := sha1.Sum(data)
+			return hex.EncodeToString(sum[:8])
+		}
+	}
+
+	if len(listing.Images) == 0 {
+		return ""
+	}
+
+	// Resize parameters change with the page layout, the image itself doesn't
+	imageURL := listing.Images[0]
+	if parsedURL, err := url.Parse(imageURL); err == nil {
+		parsedURL.RawQuery = ""
+		parsedURL.Fragment = ""
+		imageURL = parsedURL.String()
+	}
+
+	sum := sha1.Sum([]byte(imageURL))
+	return hex.EncodeToString(sum[:8])
+}
+
+// flagReposts records the image hash of each listing and marks listings whose
+// photo was seen under another id within RepostWindow as possible reposts
+func (p *AvitoParser) flagReposts(ctx context.Context, listings []*models.Listing) {
+	if p.opts.RepostWindow <= 0 {
+		return
+	}
+
+	indexer, ok := p.dbFor(ctx).(database.Indexer)
+	if !ok {
+		return
+	}
+
+	for _, listing := range listings {
+		listing.ImageHash = imageHash(listing)
+		if listing.ImageHash == "" || listing.ID == "" {
+			continue
+		}
+
+		index := database.ImageIndex(listing.ImageHash)
+		err := indexer.AddToIndex(index, listing.ID, p.opts.RepostWindow)
+		if err != nil {
+			log.Printf("Failed to index image of %s: %v", listing.ID, err)
+			continue
+		}
+
+		members, err := indexer.IndexMembers(index)
+		if err != nil {
+			continue
+		}
+
+		for _, member := range members {
+			if member != listing.ID {
+				listing.PossibleRepost = true
+				break
+			}
+		}
+		if listing.PossibleRepost {
+			log.Printf("Possible repost %s: same photo as %v", listing.ID, members)
+		}
+	}
+}
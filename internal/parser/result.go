@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"time"
+)
+
+// PageResult is what one search page of a cycle produced
+type PageResult struct {
+	Page     int    `json:"page"`
+	URL      string `json:"url"`
+	Found    int    `json:"found"`
+	New      int    `json:"new"`
+	Updated  int    `json:"updated"`
+	Filtered int    `json:"filtered"`
+	TooOld   int    `json:"too_old"`
+	// OutOfArea counts listings dropped by the location filters
+	OutOfArea int `json:"out_of_area"`
+	// NoPhotos counts listings dropped for having no photos
+	NoPhotos int `json:"no_photos"`
+}
+
+// CycleResult summarizes one ParseAllPages run
+type CycleResult struct {
+	Pages    int `json:"pages"`
+	Found    int `json:"found"`
+	New      int `json:"new"`
+	Updated  int `json:"updated"`
+	Filtered int `json:"filtered"`
+	TooOld   int `json:"too_old"`
+	// OutOfArea counts listings dropped by the location filters
+	OutOfArea int `json:"out_of_area"`
+	// NoPhotos counts listings dropped for having no photos
+	NoPhotos int `json:"no_photos"`
+	// Blocked counts page loads answered with a captcha or access-denied page
+	Blocked  int           `json:"blocked"`
+	Duration time.Duration `json:"duration_ns"`
+	PerPage  []PageResult  `json:"per_page"`
+}
+
+// addPage adds the counts of a processed page to the totals
+func (r *CycleResult) addPage(page PageResult) {
+	r.Pages++
+	r.Found += page.Found
+	r.New += page.New
+	r.Updated += page.Updated
+	r.Filtered += page.Filtered
+	r.TooOld += page.TooOld
+	r.OutOfArea += page.OutOfArea
+	r.NoPhotos += page.NoPhotos
+	r.PerPage = append(r.PerPage, page)
+}
@@ -0,0 +1,48 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// RetryBudgetError is returned when a cycle ends early because it ran out of retries
+type RetryBudgetError struct {
+	Budget int
+	Page   int
+	Err    error
+}
+
+func (e *RetryBudgetError) Error() string {
+	return fmt.Sprintf("cycle retry budget of %d exhausted on page %d: %v", e.Budget, e.Page, e.Err)
+}
+
+func (e *RetryBudgetError) Unwrap() error {
+	return e.Err
+}
+
+// retryBudget counts the retries left for a whole parsing cycle
+type retryBudget struct {
+	left      int
+	unlimited bool
+}
+
+// newRetryBudget allows n retries per cycle, 0 means no limit
+func newRetryBudget(n int) *retryBudget {
+	return &retryBudget{left: n, unlimited: n == 0}
+}
+
+// take consumes one retry, reporting false when none are left
+func (b *retryBudget) take() bool {
+	if b.unlimited {
+		return true
+	}
+	if b.left <= 0 {
+		return false
+	}
+	b.left--
+	return true
+}
+
+// exhausted reports whether all retries have been used
+func (b *retryBudget) exhausted() bool {
+	return !b.unlimited && b.left <= 0
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRetryBudget(t *testing.T) {
+	tests := []struct {
+		budget int
+		takes  int
+		want   []bool
+	}{
+		{budget: 0, takes: 5, want: []bool{true, true, true, true, true}},
+		{budget: 1, takes: 3, want: []bool{true, false, false}},
+		{budget: 3, takes: 4, want: []bool{true, true, true, false}},
+	}
+
+	for _, tt := range tests {
+		budget := newRetryBudget(tt.budget)
+		for i := 0; i < tt.takes; i++ {
+			if got := budget.take(); got != tt.want[i] {
+				t.Errorf("newRetryBudget(%d): take() #%d = %v, want %v", tt.budget, i+1, got, tt.want[i])
+			}
+		}
+		if got, want := budget.exhausted(), tt.budget > 0; got != want {
+			t.Errorf("newRetryBudget(%d): exhausted() = %v, want %v", tt.budget, got, want)
+		}
+	}
+}
+
+func TestRetryBudgetErrorUnwraps(t *testing.T) {
+	cause := errors.New("page load timeout")
+	err := error(&RetryBudgetError{Budget: 5, Page: 3, Err: cause})
+
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(%v, cause) = false, want the page error unwrapped", err)
+	}
+	if want := "cycle retry budget of 5 exhausted on page 3: page load timeout"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+// Without a browser every page fails, so each page spends its retries and the
+// cycle shows how the budget is shared across pages
+func TestCycleRetryBudgetIsShared(t *testing.T) {
+	tests := []struct {
+		budget int
+		page   int
+	}{
+		{budget: 0},
+		{budget: 2, page: 1},
+		{budget: 3, page: 2},
+		{budget: 5, page: 3},
+	}
+
+	for _, tt := range tests {
+		p := newTestParser(newMemStore(), Options{CycleRetryBudget: tt.budget})
+		_, err := p.ParseAllPages(context.Background())
+
+		var budgetErr *RetryBudgetError
+		if tt.page == 0 {
+			if err != nil {
+				t.Errorf("budget %d: ParseAllPages() error = %v, want the cycle to run out its pages", tt.budget, err)
+			}
+			continue
+		}
+		if !errors.As(err, &budgetErr) {
+			t.Errorf("budget %d: ParseAllPages() error = %v, want a RetryBudgetError", tt.budget, err)
+			continue
+		}
+		if budgetErr.Page != tt.page || budgetErr.Budget != tt.budget {
+			t.Errorf("budget %d: exhausted on page %d with budget %d, want page %d", tt.budget, budgetErr.Page, budgetErr.Budget, tt.page)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"log"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// paginationSelector matches Avito's numbered pagination control
+const paginationSelector = "[data-marker*='pagination']"
+
+// scrollInterval is the pause after each scroll for new items to load
+const scrollInterval = 1500 * time.Millisecond
+
+// loadByScrolling handles layouts without numbered pagination: it scrolls to
+// the bottom until no new items appear, the scroll cap is hit or the timeout
+// passes, and returns all items loaded so far
+func (p *AvitoParser) loadByScrolling(page *rod.Page, selector string, elements rod.Elements) rod.Elements {
+	if p.opts.MaxScrolls <= 0 {
+		return elements
+	}
+
+	pagination, err := page.Sleeper(rod.NotFoundSleeper).Element(paginationSelector)
+	if err == nil && pagination != nil {
+		return elements
+	}
+
+	log.Println("No pagination control found, loading more items by scrolling")
+	deadline := time.Now().Add(p.timeout)
+
+	for scroll := 1; scroll <= p.opts.MaxScrolls; scroll++ {
+		if time.Now().Add(scrollInterval).After(deadline) {
+			log.Printf("Scrolling stopped by timeout after %d scrolls", scroll-1)
+			break
+		}
+
+		_, err := page.Eval(`() => window.scrollTo(0, document.body.scrollHeight)`)
+		if err != nil {
+			log.Printf("Failed to scroll page: %v", err)
+			break
+		}
+		time.Sleep(scrollInterval)
+
+		loaded, err := page.Elements(selector)
+		if err != nil || len(loaded) <= len(elements) {
+			break
+		}
+
+		log.Printf("Scroll %d loaded %d more items", scroll, len(loaded)-len(elements))
+		elements = loaded
+	}
+
+	return elements
+}
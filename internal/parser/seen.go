@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"avito-parser/internal/models"
+)
+
+// seenEntry is what the cache remembers of a stored listing
+type seenEntry struct {
+	id        string
+	title     string
+	price     string
+	createdAt time.Time
+	observed  int
+	status    string
+	tracked   bool
+	expiresAt time.Time
+}
+
+// seenCache is an LRU of recently saved listings, so saving a listing that is
+// still stored needs no existence check. Entries expire together with the
+// stored record. A nil cache is disabled and misses every lookup.
+type seenCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// newSeenCache creates a cache holding up to size listings, nil when size is 0
+func newSeenCache(size int) *seenCache {
+	if size <= 0 {
+		return nil
+	}
+	return &seenCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// Get returns the fields SaveListing needs of a cached listing that hasn't expired by now
+func (c *seenCache) Get(id string, now time.Time) (*models.Listing, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*seenEntry)
+	if !now.Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, id)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return &models.Listing{
+		ID:            entry.id,
+		Title:         entry.title,
+		Price:         entry.price,
+		CreatedAt:     entry.createdAt,
+		ObservedCount: entry.observed,
+		Status:        entry.status,
+		Tracked:       entry.tracked,
+	}, true
+}
+
+// Add remembers a just saved listing whose record expires at expiresAt,
+// evicting the least recently used one when full
+func (c *seenCache) Add(listing *models.Listing, expiresAt time.Time) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &seenEntry{
+		id:        listing.ID,
+		title:     listing.Title,
+		price:     listing.Price,
+		createdAt: listing.CreatedAt,
+		observed:  listing.ObservedCount,
+		status:    listing.Status,
+		tracked:   listing.Tracked,
+		expiresAt: expiresAt,
+	}
+
+	if element, ok := c.entries[listing.ID]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[listing.ID] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*seenEntry).id)
+	}
+}
+
+// Remove forgets a listing whose record was deleted
+func (c *seenCache) Remove(id string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[id]; ok {
+		c.order.Remove(element)
+		delete(c.entries, id)
+	}
+}
+
+// Extend moves the expiry of a cached listing after its record's TTL was reset
+func (c *seenCache) Extend(id string, expiresAt time.Time) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[id]; ok {
+		element.Value.(*seenEntry).expiresAt = expiresAt
+	}
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"avito-parser/internal/clock"
+	"avito-parser/internal/models"
+)
+
+func TestSeenCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	cache := newSeenCache(3)
+	expires := clk.Now().Add(time.Hour)
+
+	for n := 1; n <= 3; n++ {
+		cache.Add(&models.Listing{ID: fmt.Sprintf("listing_%d", n)}, expires)
+	}
+	// Reading listing_1 makes listing_2 the least recently used
+	if _, ok := cache.Get("listing_1", clk.Now()); !ok {
+		t.Fatal("Get(listing_1) missed before the cache was full")
+	}
+	cache.Add(&models.Listing{ID: "listing_4"}, expires)
+	// Adding a cached listing again refreshes it, so listing_3 goes next
+	cache.Add(&models.Listing{ID: "listing_1", Price: "45 000 ₽"}, expires)
+	cache.Add(&models.Listing{ID: "listing_5"}, expires)
+
+	tests := []struct {
+		id     string
+		cached bool
+	}{
+		{"listing_1", true},
+		{"listing_2", false},
+		{"listing_3", false},
+		{"listing_4", true},
+		{"listing_5", true},
+	}
+	for _, tt := range tests {
+		if _, ok := cache.Get(tt.id, clk.Now()); ok != tt.cached {
+			t.Errorf("Get(%q) cached = %v, want %v", tt.id, ok, tt.cached)
+		}
+	}
+	if listing, _ := cache.Get("listing_1", clk.Now()); listing.Price != "45 000 ₽" {
+		t.Errorf("Get(listing_1) price = %q, want the one added last", listing.Price)
+	}
+}
+
+func TestSeenCacheExpiry(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	cache := newSeenCache(10)
+	cache.Add(&models.Listing{ID: "listing_1"}, clk.Now().Add(time.Hour))
+	cache.Add(&models.Listing{ID: "listing_2"}, clk.Now().Add(time.Hour))
+
+	clk.Advance(59 * time.Minute)
+	if _, ok := cache.Get("listing_1", clk.Now()); !ok {
+		t.Error("Get(listing_1) missed before it expired")
+	}
+	cache.Extend("listing_2", clk.Now().Add(time.Hour))
+
+	clk.Advance(time.Minute)
+	if _, ok := cache.Get("listing_1", clk.Now()); ok {
+		t.Error("Get(listing_1) hit once its record expired")
+	}
+	if _, ok := cache.Get("listing_2", clk.Now()); !ok {
+		t.Error("Get(listing_2) missed after its expiry was extended")
+	}
+	if len(cache.entries) != 1 || cache.order.Len() != 1 {
+		t.Errorf("cache holds %d entries in %d places, want the expired one dropped", len(cache.entries), cache.order.Len())
+	}
+
+	cache.Remove("listing_2")
+	if _, ok := cache.Get("listing_2", clk.Now()); ok {
+		t.Error("Get(listing_2) hit after Remove")
+	}
+}
+
+func TestSeenCacheDisabled(t *testing.T) {
+	cache := newSeenCache(0)
+	cache.Add(&models.Listing{ID: "listing_1"}, testNow.Add(time.Hour))
+	if _, ok := cache.Get("listing_1", testNow); ok {
+		t.Error("Get() hit on a disabled cache")
+	}
+}
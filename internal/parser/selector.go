@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"avito-parser/internal/util"
+
+	"github.com/go-rod/rod"
+)
+
+// errSelectorFailed marks a page where the listing selectors errored instead of matching nothing
+var errSelectorFailed = errors.New("listing selectors failed")
+
+// listingSelectors are tried in order to find listing cards on a search page
+var listingSelectors = []string{
+	"[data-marker='item']",
+	"[data-marker*='item']",
+	".item, .listing-item",
+}
+
+// titleSelectors are tried in order to find the title inside a card
+var titleSelectors = []string{
+	"[itemprop='name']",
+	"[data-marker='item-title'] a",
+	"h3 a",
+	".item-title a",
+	"[data-marker*='title'] a",
+	"a[title]",
+}
+
+// priceSelectors are tried in order to find the price inside a card
+var priceSelectors = []string{
+	"[itemprop='price']",
+	"[data-marker='item-price']",
+	".price",
+	"[data-marker*='price']",
+	".item-price",
+}
+
+// dateSelectors are tried in order to find the publication date inside a card
+var dateSelectors = []string{
+	"[data-marker='item-date']",
+	"[data-marker*='date']",
+}
+
+// locationSelectors are tried in order to find the address or district inside a card
+var locationSelectors = []string{
+	"[data-marker='item-address']",
+	"[data-marker*='address']",
+	"[class*='geo-root']",
+}
+
+// metroSelectors find the nearest metro station and walking time inside a card
+var metroSelectors = []string{
+	"[data-marker*='metro']",
+	"[class*='geo-georeferences']",
+	"[class*='metro']",
+}
+
+// badgeSelectors match all badges of a card like "Собственник"
+var badgeSelectors = []string{
+	"[data-marker*='badge']",
+	"[class*='badge']",
+}
+
+// selectorsFor returns the user's selectors for the field ahead of the built-in defaults
+func (p *AvitoParser) selectorsFor(field string, defaults []string) []string {
+	overrides := p.opts.SelectorOverrides[field]
+	if len(overrides) == 0 {
+		return defaults
+	}
+
+	merged := make([]string, 0, len(overrides)+len(defaults))
+	merged = append(merged, overrides...)
+	return append(merged, defaults...)
+}
+
+// contentPollInterval is the pause between checks for rendered search results
+const contentPollInterval = 200 * time.Millisecond
+
+// waitForContent waits until the search page shows a listing card or the
+// empty-results block, but no longer than maxWait, so a page that renders
+// quickly isn't held for the whole wait
+func (p *AvitoParser) waitForContent(page *rod.Page, maxWait time.Duration) {
+	selectors := append([]string{}, p.selectorsFor("listing", listingSelectors)...)
+	if p.opts.NoResultsSelector != "" {
+		selectors = append(selectors, p.opts.NoResultsSelector)
+	}
+
+	waitForAny(context.Background(), page, selectors, maxWait)
+}
+
+// elementChecker is the part of a page waitForAny polls, implemented by *rod.Page
+type elementChecker interface {
+	Has(selector string) (bool, *rod.Element, error)
+}
+
+// waitForAny waits until the page has an element matching one of the
+// selectors, but no longer than maxWait. It returns ctx's error if ctx is
+// cancelled first.
+func waitForAny(ctx context.Context, page elementChecker, selectors []string, maxWait time.Duration) error {
+	deadline := time.Now().Add(maxWait)
+	for {
+		for _, selector := range selectors {
+			has, _, err := page.Has(selector)
+			if err == nil && has {
+				return nil
+			}
+		}
+
+		if time.Now().Add(contentPollInterval).After(deadline) {
+			return nil
+		}
+		if err := sleep(ctx, contentPollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// findListingElements returns the cards matched by the first selector that finds any.
+// An empty result means every selector ran cleanly and matched nothing; if a
+// selector errored, the lookup is retried up to SelectorTolerance times and then
+// fails with errSelectorFailed so the page is retried rather than taken as the end.
+func (p *AvitoParser) findListingElements(page *rod.Page) (rod.Elements, string, error) {
+	var lastErr error
+	for attempt := 0; attempt <= p.opts.SelectorTolerance; attempt++ {
+		lastErr = nil
+		for _, selector := range p.selectorsFor("listing", listingSelectors) {
+			elements, err := page.Elements(selector)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if len(elements) > 0 {
+				return elements, selector, nil
+			}
+		}
+
+		if lastErr == nil {
+			return nil, "", nil
+		}
+		log.Printf("Listing selector error (attempt %d of %d): %v", attempt+1, p.opts.SelectorTolerance+1, lastErr)
+		time.Sleep(time.Second)
+	}
+	return nil, "", fmt.Errorf("%w: %v", errSelectorFailed, lastErr)
+}
+
+// fullTextAttributes may hold the complete text of an element whose visible text is cut off
+var fullTextAttributes = []string{"title", "aria-label"}
+
+// isTruncated reports whether the visible text ends with an ellipsis
+func isTruncated(text string) bool {
+	return strings.HasSuffix(text, "…") || strings.HasSuffix(text, "...")
+}
+
+// preferFullText returns the first candidate that continues the visible text
+// past its ellipsis, or the text itself when it isn't cut off or none does
+func preferFullText(text string, candidates []string) string {
+	if !isTruncated(text) {
+		return text
+	}
+
+	visible := strings.TrimRight(strings.TrimSuffix(strings.TrimSuffix(text, "…"), "..."), " ")
+	for _, candidate := range candidates {
+		candidate = util.NormalizeText(candidate)
+		if len(candidate) > len(visible) && strings.HasPrefix(candidate, visible) && !isTruncated(candidate) {
+			return candidate
+		}
+	}
+	return text
+}
+
+// fullText recovers the complete text of an element cut off with an ellipsis
+// from its title or aria-label, or from those of the link around it
+func fullText(element *rod.Element, text string) string {
+	if !isTruncated(text) {
+		return text
+	}
+
+	holders := []*rod.Element{element}
+	if links, err := element.Parents("a"); err == nil && len(links) > 0 {
+		holders = append(holders, links.First())
+	}
+
+	var candidates []string
+	for _, holder := range holders {
+		for _, attribute := range fullTextAttributes {
+			value, err := holder.Attribute(attribute)
+			if err == nil && value != nil {
+				candidates = append(candidates, *value)
+			}
+		}
+	}
+	return preferFullText(text, candidates)
+}
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// delayedPage shows the selector once it has been polled appearAfter times,
+// never when appearAfter is 0
+type delayedPage struct {
+	selector    string
+	appearAfter int
+	polls       int
+}
+
+func (d *delayedPage) Has(selector string) (bool, *rod.Element, error) {
+	if selector != d.selector {
+		return false, nil, nil
+	}
+	d.polls++
+	return d.appearAfter > 0 && d.polls >= d.appearAfter, nil, nil
+}
+
+func TestWaitForAny(t *testing.T) {
+	tests := []struct {
+		name        string
+		appearAfter int
+		maxWait     time.Duration
+		cancel      bool
+		minElapsed  time.Duration
+		maxElapsed  time.Duration
+		err         error
+	}{
+		{"shown at once", 1, time.Minute, false, 0, contentPollInterval / 2, nil},
+		{"shown on a later poll", 3, time.Minute, false, 2 * contentPollInterval, 4 * contentPollInterval, nil},
+		{"never shown", 0, time.Second, false, time.Second - contentPollInterval, time.Second + contentPollInterval, nil},
+		{"no wait configured", 0, 0, false, 0, contentPollInterval / 2, nil},
+		{"cancelled", 0, time.Minute, true, 0, contentPollInterval, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := &delayedPage{selector: "h1", appearAfter: tt.appearAfter}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			start := time.Now()
+			err := waitForAny(ctx, page, []string{"[data-marker='title']", "h1"}, tt.maxWait)
+			elapsed := time.Since(start)
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("waitForAny() error = %v, want %v", err, tt.err)
+			}
+			if elapsed < tt.minElapsed || elapsed > tt.maxElapsed {
+				t.Errorf("waitForAny() took %v, want %v to %v", elapsed, tt.minElapsed, tt.maxElapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"avito-parser/internal/database"
+	"avito-parser/internal/models"
+)
+
+// watchTarget resolves a watch list entry to the listing id and the URL of its
+// page. Avito redirects /<id> to the listing, so a bare id needs no full URL.
+func (p *AvitoParser) watchTarget(entry string) (id, pageURL string) {
+	if _, err := strconv.ParseUint(entry, 10, 64); err == nil {
+		return "listing_" + entry, "https://www.avito.ru/" + entry
+	}
+	return p.opts.IDStrategy.ID(&models.Listing{URL: entry}), entry
+}
+
+// CheckWatchlist fetches the page of every watched listing, saves it as
+// tracked and logs changes of its price or status, including removal. Pages
+// are fetched one by one with the page delay between them; a blocked page
+// ends the pass.
+func (p *AvitoParser) CheckWatchlist(ctx context.Context) {
+	if len(p.opts.Watchlist) == 0 {
+		return
+	}
+
+	p.cycleMu.Lock()
+	defer p.cycleMu.Unlock()
+
+	log.Printf("Checking %d watched listings...", len(p.opts.Watchlist))
+
+	db := p.dbFor(ctx)
+	for i, entry := range p.opts.Watchlist {
+		if i > 0 {
+			if delay := p.opts.NextDelay(); delay > 0 {
+				if err := sleep(ctx, delay); err != nil {
+					return
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := p.checkWatched(ctx, db, entry); errors.Is(err, errBlocked) {
+			log.Printf("Watch list check stopped, Avito blocked the page of %s", entry)
+			return
+		}
+	}
+}
+
+// checkWatched fetches one watched listing and saves its current state. A
+// listing whose page fails to load is left as stored and the fetch error is
+// returned, errBlocked for a blocked page; other failures are only logged.
+func (p *AvitoParser) checkWatched(ctx context.Context, db database.Store, entry string) error {
+	id, pageURL := p.watchTarget(entry)
+
+	stored, err := loadListing(db, id)
+	if err != nil {
+		log.Printf("Failed to load watched listing %s: %v", id, err)
+		return nil
+	}
+	if stored != nil && stored.URL != "" {
+		pageURL = stored.URL
+	}
+
+	detail, err := p.details(ctx, pageURL)
+	if err != nil {
+		log.Printf("Watched listing %s is unreachable: %v", id, err)
+		return err
+	}
+
+	now := p.now()
+	var listing *models.Listing
+	if stored != nil {
+		updated := *stored
+		listing = &updated
+	} else {
+		// Nothing to compare with until the listing was seen once
+		if detail.Removed {
+			log.Printf("Watched listing %s is not on Avito", id)
+			return nil
+		}
+		listing = &models.Listing{
+			ID:            id,
+			URL:           pageURL,
+			Title:         detail.Title,
+			CreatedAt:     now,
+			ObservedCount: 1,
+		}
+	}
+
+	mergeDetail(listing, detail, now, p.opts.FXRates)
+	listing.Tracked = true
+
+	data, err := p.project(listing).ToJSON()
+	if err != nil {
+		log.Printf("Failed to convert watched listing %s to JSON: %v", id, err)
+		return nil
+	}
+
+	if stored == nil {
+		p.makeRoom(db, 1)
+	}
+
+	err = db.Set(listing.ID, string(data), listingTTL)
+	if err != nil {
+		log.Printf("Failed to save watched listing %s: %v", id, err)
+		return nil
+	}
+	p.seen.Add(p.project(listing), now.Add(listingTTL))
+	p.indexStored(db, []*models.Listing{listing})
+
+	if stored == nil {
+		log.Printf("Watching listing %s: %s - %s", id, listing.Title, listing.Price)
+		return nil
+	}
+	if change := models.NewListingChange(stored, p.project(listing)); change != nil {
+		log.Printf("Watched listing %s changed: %s", id, change)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"avito-parser/internal/parser"
+)
+
+// restartDelay is the pause before a city whose parsing stopped is started again
+const restartDelay = time.Minute
+
+// City is one search parsed by its own parser and browser
+type City struct {
+	Name   string
+	Parser *parser.AvitoParser
+}
+
+// Supervisor parses several cities side by side, each in its own browser so
+// cookies and sessions aren't shared. A city that fails to start or stops is
+// restarted on its own while the others keep running.
+type Supervisor struct {
+	cities []City
+	warmup bool
+}
+
+// NewSupervisor creates a supervisor of the cities, visiting each city's
+// homepage after its browser starts when warmup is set
+func NewSupervisor(cities []City, warmup bool) *Supervisor {
+	return &Supervisor{cities: cities, warmup: warmup}
+}
+
+// Run parses every city until ctx is cancelled and returns once all of their
+// browsers are closed
+func (s *Supervisor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, city := range s.cities {
+		wg.Add(1)
+		go func(city City) {
+			defer wg.Done()
+			s.runCity(ctx, city)
+		}(city)
+	}
+	wg.Wait()
+}
+
+// runCity keeps one city parsing, restarting it after restartDelay when it stops
+func (s *Supervisor) runCity(ctx context.Context, city City) {
+	for {
+		s.parseCity(ctx, city)
+
+		err := city.Parser.Close()
+		if err != nil {
+			log.Printf("[%s] Error closing browser: %v", city.Name, err)
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Printf("[%s] Parsing stopped, restarting in %v", city.Name, restartDelay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(restartDelay):
+		}
+	}
+}
+
+// parseCity starts the browser of a city and parses until its loop stops.
+// A panic is contained so it can't take the other cities down.
+func (s *Supervisor) parseCity(ctx context.Context, city City) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[%s] Recovered from panic: %v", city.Name, r)
+		}
+	}()
+
+	err := city.Parser.Start()
+	if err != nil {
+		log.Printf("[%s] Failed to start parser: %v", city.Name, err)
+		return
+	}
+
+	if s.warmup {
+		if err := city.Parser.Warmup(ctx); err != nil {
+			log.Printf("[%s] Warm-up failed: %v", city.Name, err)
+		}
+	}
+
+	log.Printf("[%s] Starting continuous multi-page parsing...", city.Name)
+	city.Parser.StartContinuousParsing(ctx)
+}
+
+// CityName names a search by the city segment of its URL, e.g. chelyabinsk,
+// falling back to the whole URL
+func CityName(baseURL string) string {
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+
+	segment, _, _ := strings.Cut(strings.Trim(parsedURL.Path, "/"), "/")
+	if segment == "" {
+		return baseURL
+	}
+	return segment
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// isDigitSeparator reports whether r groups thousands in Russian formatting:
+// a regular, no-break, narrow no-break, figure or thin space
+func isDigitSeparator(r rune) bool {
+	switch r {
+	case ' ', '\u00a0', '\u202f', '\u2007', '\u2009':
+		return true
+	}
+	return false
+}
+
+// ParseRussianInt reads the first number of a text like "от 1 234 567 ₽/мес.",
+// whose thousands are grouped by any kind of space. Text before the number and
+// anything after it is ignored. It fails when the text has no digits.
+func ParseRussianInt(s string) (int64, error) {
+	start := strings.IndexAny(s, "0123456789")
+	if start < 0 {
+		return 0, fmt.Errorf("no digits in %q", s)
+	}
+
+	var digits strings.Builder
+	runes := []rune(s[start:])
+	for i, r := range runes {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+			continue
+		}
+		// A separator only continues the number when a digit follows it
+		if isDigitSeparator(r) && i+1 < len(runes) && runes[i+1] >= '0' && runes[i+1] <= '9' {
+			continue
+		}
+		break
+	}
+
+	value, err := strconv.ParseInt(digits.String(), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number in %q: %w", s, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,36 @@
+package util
+
+import "testing"
+
+func TestParseRussianInt(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "25000", want: 25000},
+		{input: "25 000 ₽ в месяц", want: 25000},
+		{input: "от 1 234 567 ₽/мес.", want: 1234567},
+		{input: "45 000 ₽", want: 45000},
+		{input: "45 000 ₽", want: 45000},
+		{input: "45 000", want: 45000},
+		{input: "45 000", want: 45000},
+		{input: "3 комнаты, 12 000 ₽", want: 3},
+		{input: "12 000 ₽ 5", want: 12000},
+		{input: "1 000 ", want: 1000},
+		{input: "Цена не указана", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "99999999999999999999", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRussianInt(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRussianInt(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRussianInt(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package util
+
+import (
+	"strings"
+)
+
+// spaceReplacer turns non-breaking space variants into regular spaces
+var spaceReplacer = strings.NewReplacer(
+	"\u00a0", " ", // no-break space
+	"\u202f", " ", // narrow no-break space
+	"\u2007", " ", // figure space
+)
+
+// NormalizeText trims the text and collapses any whitespace, including
+// non-breaking spaces, into single regular spaces
+func NormalizeText(s string) string {
+	return strings.Join(strings.Fields(spaceReplacer.Replace(s)), " ")
+}
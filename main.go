@@ -1,54 +1,280 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"math/rand"
+	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
+	_ "time/tzdata" // Timezones for TIMEZONE in minimal containers
 
+	"avito-parser/internal/clock"
 	"avito-parser/internal/config"
 	"avito-parser/internal/database"
+	"avito-parser/internal/export"
+	"avito-parser/internal/models"
 	"avito-parser/internal/parser"
+	"avito-parser/internal/runner"
 )
 
 func main() {
+	// Parse command line flags
+	baseURL := flag.String("url", "", "Avito search URL, overrides AVITO_URL")
+	debug := flag.Bool("debug", false, "analyze page structure instead of parsing, same as DEBUG=true")
+	refresh := flag.Bool("refresh", false, "re-check all stored listings on their own pages and exit")
+	replay := flag.String("replay", "", "parse a saved HTML page or directory of pages, print the listings as JSON and exit")
+	baseline := flag.Bool("baseline", false, "record selector match counts on the base URL as the drift baseline and exit")
+	checkSelectors := flag.Bool("check-selectors", false, "report selector matches on the base URL and exit, non-zero if no listings match")
+	exportFormat := flag.String("export", "", "export stored listings as json or jsonl and exit")
+	exportOut := flag.String("out", "", "export output file, stdout when empty")
+	exportIndent := flag.Int("indent", 2, "number of spaces to indent -export json with")
+	dedupReport := flag.Bool("dedup-report", false, "print groups of stored listings sharing a fingerprint or photo and exit")
+	purge := flag.Bool("purge", false, "delete stored listings matching all given -purge-* filters and exit")
+	purgePriceAbove := flag.Int64("purge-price-above", 0, "with -purge, match listings priced above this many rubles")
+	purgeBefore := flag.String("purge-before", "", "with -purge, match listings first seen before this date, YYYY-MM-DD")
+	purgeStatus := flag.String("purge-status", "", "with -purge, match listings with this status: active, archived or removed")
+	purgeYes := flag.Bool("yes", false, "with -purge, delete without asking for confirmation")
+	dryRun := flag.Bool("dry-run", false, "read storage to log new and changed listings but write nothing, same as DRY_RUN=true")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
-	// Initialize Redis client
-	redisClient, err := database.NewRedisClient(
-		cfg.Redis.Host,
-		cfg.Redis.Port,
-		cfg.Redis.Password,
-		cfg.Redis.DB,
+	// Command line URL takes precedence over the environment
+	if *baseURL != "" {
+		err := config.ValidateAvitoURL(*baseURL)
+		if err != nil {
+			log.Fatalf("Invalid -url flag: %v", err)
+		}
+		cfg.Avito.BaseURL = *baseURL
+		cfg.Avito.BaseURLs = nil
+	}
+
+	// Initialize storage: one JSON file per listing, Redis, SQLite or several of them
+	stores := make([]database.Store, 0, len(cfg.Storage.Backends))
+	for _, backend := range cfg.Storage.Backends {
+		backendStore, err := openStore(cfg, backend)
+		if err != nil {
+			log.Fatalf("Failed to initialize %s storage: %v", backend, err)
+		}
+		stores = append(stores, backendStore)
+	}
+
+	var store database.Store = stores[0]
+	if len(stores) > 1 {
+		log.Printf("Writing listings to %v, %s decides what is new", cfg.Storage.Backends, cfg.Storage.Backends[0])
+		store = database.NewMultiStore(stores[0], stores[1:]...)
+	}
+
+	// Keep reading storage for update detection but drop every write
+	if *dryRun || cfg.Storage.DryRun {
+		log.Println("Dry run: listings are compared with storage but not saved")
+		store = database.NewReadOnlyStore(store)
+	}
+
+	// Export stored listings without starting the browser
+	if *exportFormat != "" {
+		err := runExport(store, *exportFormat, *exportOut, *exportIndent)
+		store.Close()
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		return
+	}
+
+	// Report likely duplicates without starting the browser
+	if *dedupReport {
+		clusters, err := export.DedupReport(os.Stdout, store)
+		store.Close()
+		if err != nil {
+			log.Fatalf("Duplicate report failed: %v", err)
+		}
+		log.Printf("Found %d groups of likely duplicates", clusters)
+		return
+	}
+
+	// Report the size of the stored dataset
+	if count, err := database.Count(store); err != nil {
+		log.Printf("Failed to count stored listings: %v", err)
+	} else {
+		log.Printf("Storage holds %d listings", count)
+	}
+
+	// Serve profiles of the running parser when enabled
+	startPprof(cfg.Parser.PprofAddr)
+
+	// Resolve listing ID strategy
+	idStrategy, err := models.IDStrategyByName(cfg.Parser.IDStrategy)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Check the projection of stored listings
+	if err := models.ValidateFields(cfg.Parser.StoreFields); err != nil {
+		log.Fatalf("Invalid STORE_FIELDS: %v", err)
+	}
+
+	// Resolve the browser profile
+	browserProfile, err := parser.BrowserProfileByName(cfg.Browser.Profile)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Build the page delay generator, seeded for reproducible runs when DELAY_SEED is set
+	delaySeed := cfg.Parser.DelaySeed
+	if delaySeed == 0 {
+		delaySeed = time.Now().UnixNano()
+	}
+	nextDelay, err := parser.NewDelayFunc(
+		cfg.Parser.DelayDistribution,
+		cfg.Parser.PageDelay,
+		cfg.Parser.DelaySpread,
+		cfg.Parser.DelayFloor,
+		rand.New(rand.NewSource(delaySeed)),
 	)
 	if err != nil {
-		log.Fatalf("Failed to initialize Redis client: %v", err)
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Parser options shared by every search
+	opts := parser.Options{
+		ElementWait:       cfg.Parser.ElementWait,
+		ContentWait:       cfg.Parser.ContentWait,
+		ProbeWait:         cfg.Parser.ProbeWait,
+		RetryDelay:        cfg.Parser.RetryDelay,
+		SelectorTolerance: cfg.Parser.SelectorTolerance,
+		SelectorOverrides: cfg.Parser.SelectorOverrides,
+		IDStrategy:        idStrategy,
+		NextDelay:         nextDelay,
+
+		MinTitleLength:     cfg.Parser.MinTitleLength,
+		RejectTitles:       cfg.Parser.RejectTitles,
+		RequirePriceDigits: cfg.Parser.RequirePriceDigits,
+		MinPrice:           cfg.Parser.MinPrice,
+		MaxPrice:           cfg.Parser.MaxPrice,
+		FXRates:            cfg.Parser.FXRates,
+
+		BufferSize:      cfg.Parser.BufferSize,
+		SaveMaxAttempts: cfg.Parser.SaveMaxAttempts,
+		SeenCacheSize:   cfg.Parser.SeenCacheSize,
+		MaxStored:       cfg.Parser.MaxStored,
+
+		BlockKeywords: cfg.Parser.BlockKeywords,
+		BodyScanLimit: cfg.Parser.BodyScanLimit,
+
+		BlockAlertThreshold: cfg.Parser.BlockAlertThreshold,
+		BlockAlertWindow:    cfg.Parser.BlockAlertWindow,
+		BlockAlertCooldown:  cfg.Parser.BlockAlertCooldown,
+
+		NoResultsSelector: cfg.Parser.NoResultsSelector,
+		NoResultsText:     cfg.Parser.NoResultsText,
+
+		SkipArchived: cfg.Parser.SkipArchived,
+		RequireTags:  cfg.Parser.RequireTags,
+		ExcludeTags:  cfg.Parser.ExcludeTags,
+
+		Locale:      cfg.Browser.Locale,
+		Profile:     browserProfile,
+		UserDataDir: cfg.Browser.UserDataDir,
+		RevealPhone: cfg.Browser.RevealPhone,
+
+		BrowserLifetime: cfg.Browser.Lifetime,
+
+		Proxies:             cfg.Browser.Proxies,
+		ProxyMaxFailures:    cfg.Browser.ProxyMaxFailures,
+		ProxyRotateEachPage: cfg.Browser.ProxyRotateEachPage,
+
+		CycleRetryBudget: cfg.Parser.CycleRetryBudget,
+		CycleTimeout:     cfg.Parser.CycleTimeout,
+		MaxScrolls:       cfg.Parser.MaxScrolls,
+		MaxElements:      cfg.Parser.MaxElements,
+		SortByDate:       cfg.Parser.SortByDate,
+
+		MaxAge:         cfg.Parser.MaxAge,
+		KeepUnknownAge: cfg.Parser.KeepUnknownAge,
+
+		NewWindow: cfg.Parser.NewWindow,
+
+		IncludeLocations:    cfg.Parser.IncludeLocations,
+		ExcludeLocations:    cfg.Parser.ExcludeLocations,
+		IncludeMetro:        cfg.Parser.IncludeMetro,
+		KeepUnknownLocation: cfg.Parser.KeepUnknownLocation,
+
+		RequireImages: cfg.Parser.RequireImages,
+
+		Location:   cfg.Parser.Location,
+		QuietHours: cfg.Parser.QuietHours,
+
+		ImageDir:     cfg.Parser.ImageDir,
+		RepostWindow: cfg.Parser.RepostWindow,
+
+		Watchlist: cfg.Parser.Watchlist,
+
+		SnapshotDir:       cfg.Parser.SnapshotDir,
+		SnapshotRetention: cfg.Parser.SnapshotRetention,
+		CycleCommand:      cfg.Parser.CycleCommand,
+		MetricsFile:       cfg.Parser.MetricsFile,
+		StoreFields:       cfg.Parser.StoreFields,
+
+		RawHTMLDir:       cfg.Parser.RawHTMLDir,
+		RawHTMLMaxFiles:  cfg.Parser.RawHTMLMaxFiles,
+		RawHTMLRetention: cfg.Parser.RawHTMLRetention,
+
+		DryRun: *dryRun || cfg.Storage.DryRun,
 	}
-	defer redisClient.Close()
 
 	// Initialize Avito parser with new parameters
 	avitoParser := parser.NewAvitoParser(
-		redisClient,
+		store,
 		cfg.Browser.Headless,
 		cfg.Browser.Timeout,
 		cfg.Avito.BaseURL,
 		cfg.Parser.CycleDelay,
 		cfg.Parser.PageDelay,
+		opts,
 	)
 
+	// Delete matching listings without starting the browser
+	if *purge {
+		match, err := purgeFilter(*purgePriceAbove, *purgeBefore, *purgeStatus, cfg.Parser.Location)
+		if err == nil {
+			err = runPurge(store, avitoParser, match, *purgeYes, *dryRun || cfg.Storage.DryRun)
+		}
+		store.Close()
+		if err != nil {
+			log.Fatalf("Purge failed: %v", err)
+		}
+		return
+	}
+
 	// Start browser
 	err = avitoParser.Start()
 	if err != nil {
+		store.Close()
 		log.Fatalf("Failed to start parser: %v", err)
 	}
-	defer avitoParser.Close()
+
+	// Close the browser before storage so a hanging browser can't keep Redis open
+	defer shutdown(context.Background(), shutdownStepTimeout,
+		shutdownStep{"browser", avitoParser.Close},
+		shutdownStep{"storage", store.Close},
+	)
 
 	// Check if debug mode is enabled
-	if os.Getenv("DEBUG") == "true" {
+	if *debug || os.Getenv("DEBUG") == "true" {
 		log.Println("=== DEBUG MODE ENABLED ===")
 		err := avitoParser.DebugPage(cfg.Avito.BaseURL)
 		if err != nil {
@@ -57,20 +283,410 @@ func main() {
 		return
 	}
 
+	// Parse saved pages offline, e.g. to try selector changes
+	if *replay != "" {
+		listings, err := avitoParser.Replay(*replay)
+		if err == nil {
+			err = export.Write(os.Stdout, "json", listings, *exportIndent)
+		}
+		if err != nil {
+			avitoParser.Close()
+			log.Fatalf("Replay failed: %v", err)
+		}
+		return
+	}
+
+	// Record what the markup looks like now so cycles can detect changes
+	if *baseline {
+		reports, err := avitoParser.RecordBaseline()
+		if err != nil {
+			avitoParser.Close()
+			log.Fatalf("Failed to record baseline: %v", err)
+		}
+		log.Printf("Recorded selector baseline with %d selectors", len(reports))
+		return
+	}
+
+	// Report how the parser's selectors match the live page, e.g. as a scheduled canary
+	if *checkSelectors {
+		reports, err := avitoParser.CheckSelectors()
+		for _, report := range reports {
+			if report.Err != nil {
+				log.Printf("%-10s %-32s error: %v", report.Set, report.Selector, report.Err)
+				continue
+			}
+			log.Printf("%-10s %-32s %d", report.Set, report.Selector, report.Matches)
+		}
+		if err != nil {
+			avitoParser.Close()
+			log.Fatalf("Selector check failed: %v", err)
+		}
+		return
+	}
+
+	// Visit the homepage first to establish a session
+	if cfg.Browser.Warmup {
+		err := avitoParser.Warmup(context.Background())
+		if err != nil {
+			log.Printf("Warm-up failed: %v", err)
+		}
+	}
+
+	// Refresh stored listings instead of parsing search pages
+	if *refresh {
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		err := avitoParser.RefreshAll(ctx)
+		if err != nil {
+			log.Printf("Refresh failed: %v", err)
+		}
+		return
+	}
+
 	// Set up graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Start continuous parsing in a separate goroutine
-	go func() {
-		log.Println("Starting continuous multi-page parsing...")
-		avitoParser.StartContinuousParsing()
-	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	if len(cfg.Avito.BaseURLs) > 1 {
+		// Every city gets its own browser, the first parser's isn't needed
+		avitoParser.Close()
+		supervisor := runner.NewSupervisor(newCities(cfg, store, opts, delaySeed), cfg.Browser.Warmup)
+		go func() {
+			defer close(done)
+			log.Printf("Parsing %d cities, at most %d at once", len(cfg.Avito.BaseURLs), cfg.Avito.Concurrency)
+			supervisor.Run(ctx)
+		}()
+	} else {
+		go func() {
+			defer close(done)
+			log.Println("Starting continuous multi-page parsing...")
+			avitoParser.StartContinuousParsing(ctx)
+		}()
+	}
 
 	log.Println("Avito multi-page parser started. Press Ctrl+C to stop.")
-	log.Println("To enable debug mode, set DEBUG=true environment variable")
-	
-	// Wait for shutdown signal
-	<-sigChan
+	log.Println("To enable debug mode, set DEBUG=true environment variable or pass -debug")
+
+	// Stop on its own after the max runtime if configured
+	if cfg.Parser.MaxRuntime > 0 {
+		log.Printf("Parser will exit after %v", cfg.Parser.MaxRuntime)
+	}
+	waitForStop(sigChan, clock.Real{}, cfg.Parser.MaxRuntime)
 	log.Println("Shutting down gracefully...")
-}
\ No newline at end of file
+
+	// Let the in-flight page finish, but don't wait forever for it
+	if !stopParsing(cancel, done, cfg.Browser.Timeout) {
+		log.Println("Parsing did not stop in time, forcing shutdown")
+	}
+}
+
+// waitForStop blocks until a shutdown signal arrives or, when maxRuntime is
+// set, until it has passed on clk
+func waitForStop(signals <-chan os.Signal, clk clock.Clock, maxRuntime time.Duration) {
+	var expired <-chan time.Time
+	if maxRuntime > 0 {
+		expired = clk.After(maxRuntime)
+	}
+
+	select {
+	case <-signals:
+	case <-expired:
+		log.Println("Max runtime reached")
+	}
+}
+
+// stopParsing cancels the parsing and waits for done, at most grace. It
+// reports whether the parsing stopped in time.
+func stopParsing(cancel context.CancelFunc, done <-chan struct{}, grace time.Duration) bool {
+	cancel()
+	select {
+	case <-done:
+		return true
+	case <-time.After(grace):
+		return false
+	}
+}
+
+// shutdownStepTimeout bounds how long a single resource may take to close
+const shutdownStepTimeout = 10 * time.Second
+
+// shutdownStep closes one resource on exit
+type shutdownStep struct {
+	name  string
+	close func() error
+}
+
+// shutdown closes the resources in order, giving each at most stepTimeout. A
+// step that doesn't finish in time is left running and the next one starts
+// anyway. Close errors are logged and returned joined.
+func shutdown(ctx context.Context, stepTimeout time.Duration, steps ...shutdownStep) error {
+	var errs []error
+	for _, step := range steps {
+		stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+
+		result := make(chan error, 1)
+		go func(step shutdownStep) {
+			result <- step.close()
+		}(step)
+
+		select {
+		case err := <-result:
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to close %s: %w", step.name, err))
+			}
+		case <-stepCtx.Done():
+			errs = append(errs, fmt.Errorf("closing %s did not finish in %v", step.name, stepTimeout))
+		}
+		cancel()
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		log.Printf("Shutdown errors: %v", err)
+	}
+	return err
+}
+
+// startPprof serves the net/http/pprof handlers on addr in the background,
+// nothing is served when addr is empty
+func startPprof(addr string) {
+	server := pprofServer(addr)
+	if server == nil {
+		return
+	}
+
+	go func() {
+		log.Printf("Serving pprof on http://%s/debug/pprof/", addr)
+		err := server.ListenAndServe()
+		if err != nil {
+			log.Printf("pprof server stopped: %v", err)
+		}
+	}()
+}
+
+// pprofServer returns a server of the pprof handlers on addr, nil when addr is empty
+func pprofServer(addr string) *http.Server {
+	if addr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return &http.Server{Addr: addr, Handler: mux}
+}
+
+// newCities creates a parser for every city of AVITO_URLS. Each one has its own
+// browser profile, delay generator and key namespace, and all of them share
+// the store and a bound on how many parse at once.
+func newCities(cfg *config.Config, store database.Store, opts parser.Options, delaySeed int64) []runner.City {
+	slots := make(chan struct{}, cfg.Avito.Concurrency)
+	names := make(map[string]int)
+
+	cities := make([]runner.City, 0, len(cfg.Avito.BaseURLs))
+	for i, baseURL := range cfg.Avito.BaseURLs {
+		name := runner.CityName(baseURL)
+		names[name]++
+		if names[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, names[name])
+		}
+
+		cityOpts := opts
+		cityOpts.KeyNamespace = name
+		cityOpts.CycleSlots = slots
+		if opts.UserDataDir != "" {
+			cityOpts.UserDataDir = filepath.Join(opts.UserDataDir, name)
+		}
+		// The watch list and snapshots cover the whole store, the first city handles them
+		if i > 0 {
+			cityOpts.Watchlist = nil
+			cityOpts.SnapshotDir = ""
+		}
+		// Each city counts its own cycles, e.g. metrics.prom becomes metrics-chelyabinsk.prom
+		if opts.MetricsFile != "" {
+			ext := filepath.Ext(opts.MetricsFile)
+			cityOpts.MetricsFile = strings.TrimSuffix(opts.MetricsFile, ext) + "-" + name + ext
+		}
+
+		// A delay generator isn't safe for concurrent use, so each city gets one
+		nextDelay, err := parser.NewDelayFunc(
+			cfg.Parser.DelayDistribution,
+			cfg.Parser.PageDelay,
+			cfg.Parser.DelaySpread,
+			cfg.Parser.DelayFloor,
+			rand.New(rand.NewSource(delaySeed+int64(i))),
+		)
+		if err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		cityOpts.NextDelay = nextDelay
+
+		cities = append(cities, runner.City{
+			Name: name,
+			Parser: parser.NewAvitoParser(
+				store,
+				cfg.Browser.Headless,
+				cfg.Browser.Timeout,
+				baseURL,
+				cfg.Parser.CycleDelay,
+				cfg.Parser.PageDelay,
+				cityOpts,
+			),
+		})
+	}
+	return cities
+}
+
+// openStore connects to one storage backend
+func openStore(cfg *config.Config, backend string) (database.Store, error) {
+	switch backend {
+	case config.BackendFile:
+		return database.NewFileStore(cfg.FileStore.Dir)
+	case config.BackendSQLite:
+		return database.NewSQLiteStore(cfg.SQLite.Path)
+	}
+
+	if cfg.Redis.Category != "" {
+		log.Printf("Using Redis DB %d for category %s", cfg.Redis.DB, cfg.Redis.Category)
+	}
+
+	// Redis may still be starting, e.g. in docker-compose
+	return database.ConnectWithRetry(clock.Real{}, cfg.Redis.ConnectRetries, cfg.Redis.ConnectBackoff, func() (database.Store, error) {
+		return database.NewRedisClientWithTimeout(
+			cfg.Redis.Host,
+			cfg.Redis.Port,
+			cfg.Redis.Password,
+			cfg.Redis.DB,
+			cfg.Redis.Compress,
+			cfg.Redis.Verbose,
+			cfg.Redis.OpTimeout,
+		)
+	})
+}
+
+// purgeFilter matches the listings meeting every given criterion: priced above
+// priceAbove rubles, first seen before the date and with the status
+func purgeFilter(priceAbove int64, before, status string, location *time.Location) (func(*models.Listing) bool, error) {
+	if priceAbove <= 0 && before == "" && status == "" {
+		return nil, errors.New("no filter given, use -purge-price-above, -purge-before or -purge-status")
+	}
+
+	var beforeTime time.Time
+	if before != "" {
+		if location == nil {
+			location = time.Local
+		}
+		var err error
+		beforeTime, err = time.ParseInLocation("2006-01-02", before, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -purge-before: %w", err)
+		}
+	}
+
+	return func(listing *models.Listing) bool {
+		if priceAbove > 0 {
+			price := listing.PriceRUB
+			if price == 0 {
+				price, _ = listing.PriceValue()
+			}
+			if price <= priceAbove {
+				return false
+			}
+		}
+		if !beforeTime.IsZero() && !listing.CreatedAt.Before(beforeTime) {
+			return false
+		}
+		if status != "" && listing.Status != status {
+			return false
+		}
+		return true
+	}, nil
+}
+
+// runPurge counts the listings match selects, asks for confirmation unless
+// yes is set and deletes them through the parser, which also drops what is
+// kept about them. A dry run only reports how many would be deleted.
+func runPurge(store database.Store, avitoParser *parser.AvitoParser, match func(*models.Listing) bool, yes, dryRun bool) error {
+	matched := 0
+	err := export.Each(store, func(listing *models.Listing) error {
+		if match(listing) {
+			matched++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%d stored listings match the purge filters", matched)
+	if matched == 0 {
+		return nil
+	}
+	if dryRun {
+		log.Printf("Dry run: would delete %d listings", matched)
+		return nil
+	}
+
+	if !yes {
+		fmt.Fprintf(os.Stderr, "Delete %d listings? [y/N] ", matched)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			log.Println("Purge cancelled")
+			return nil
+		}
+	}
+
+	deleted, err := avitoParser.Purge(match)
+	log.Printf("Purged %d listings", deleted)
+	return err
+}
+
+// runExport writes all stored listings to the output file or stdout. JSONL is
+// streamed page by page, a JSON array needs all listings loaded first.
+func runExport(store database.Store, format, out string, indent int) error {
+	if format != "json" && format != "jsonl" {
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+
+	w := os.Stdout
+	if out != "" {
+		var err error
+		w, err = os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+
+	if format == "jsonl" {
+		written, err := export.StreamJSONL(w, store)
+		if err != nil {
+			return err
+		}
+		log.Printf("Exported %d listings", written)
+		return nil
+	}
+
+	listings, err := export.Load(store)
+	if err != nil {
+		return err
+	}
+
+	err = export.Write(w, format, listings, indent)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Exported %d listings", len(listings))
+	return nil
+}
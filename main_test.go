@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"avito-parser/internal/clock"
+)
+
+// waitForWaiter waits until something waits on the fake clock
+func waitForWaiter(t *testing.T, clk *clock.Fake) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clk.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("nothing waits on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWaitForStopAfterMaxRuntime(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	stopped := make(chan struct{})
+	go func() {
+		waitForStop(make(chan os.Signal), clk, time.Hour)
+		close(stopped)
+	}()
+
+	waitForWaiter(t, clk)
+	clk.Advance(59 * time.Minute)
+	select {
+	case <-stopped:
+		t.Fatal("waitForStop() returned before the max runtime passed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clk.Advance(time.Minute)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("waitForStop() didn't return once the max runtime passed")
+	}
+}
+
+func TestWaitForStopOnSignal(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	signals := make(chan os.Signal, 1)
+	stopped := make(chan struct{})
+	go func() {
+		waitForStop(signals, clk, 0)
+		close(stopped)
+	}()
+
+	clk.Advance(24 * 365 * time.Hour)
+	select {
+	case <-stopped:
+		t.Fatal("waitForStop() without a max runtime returned without a signal")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if n := clk.Waiters(); n != 0 {
+		t.Errorf("waitForStop() without a max runtime set %d timers", n)
+	}
+
+	signals <- os.Interrupt
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("waitForStop() didn't return on a signal")
+	}
+}
+
+func TestStopParsing(t *testing.T) {
+	tests := []struct {
+		name    string
+		stuck   bool
+		stopped bool
+	}{
+		{"cycle ends when cancelled", false, true},
+		{"cycle stuck", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan struct{})
+			go func() {
+				if !tt.stuck {
+					<-ctx.Done()
+					close(done)
+				}
+			}()
+
+			if got := stopParsing(cancel, done, 50*time.Millisecond); got != tt.stopped {
+				t.Errorf("stopParsing() = %v, want %v", got, tt.stopped)
+			}
+			if ctx.Err() == nil {
+				t.Error("stopParsing() didn't cancel the parsing")
+			}
+		})
+	}
+}
+
+func TestPprofServer(t *testing.T) {
+	if server := pprofServer(""); server != nil {
+		t.Fatalf("pprofServer(\"\") = %v, want nothing served without PPROF_ADDR", server)
+	}
+
+	server := pprofServer("localhost:6060")
+	if server == nil || server.Addr != "localhost:6060" {
+		t.Fatalf("pprofServer() = %v, want a server on localhost:6060", server)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/debug/pprof/", http.StatusOK},
+		{"/debug/pprof/goroutine?debug=1", http.StatusOK},
+		{"/debug/pprof/heap", http.StatusOK},
+		{"/debug/pprof/cmdline", http.StatusOK},
+		{"/debug/pprof/symbol", http.StatusOK},
+		{"/", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.status {
+			t.Errorf("GET %s = %d, want %d", tt.path, recorder.Code, tt.status)
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	var mu sync.Mutex
+	var closed []string
+	step := func(name string, err error) shutdownStep {
+		return shutdownStep{name, func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			closed = append(closed, name)
+			return err
+		}}
+	}
+
+	// The browser hangs until the test ends
+	release := make(chan struct{})
+	defer close(release)
+	stuck := shutdownStep{"browser", func() error {
+		<-release
+		return nil
+	}}
+
+	notifierErr := errors.New("webhook unreachable")
+	storageErr := errors.New("connection reset")
+
+	start := time.Now()
+	err := shutdown(context.Background(), 50*time.Millisecond,
+		step("parser", nil),
+		stuck,
+		step("notifier", notifierErr),
+		step("storage", storageErr),
+	)
+	elapsed := time.Since(start)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"parser", "notifier", "storage"}; !reflect.DeepEqual(closed, want) {
+		t.Errorf("closed %v, want %v in order after the stuck browser", closed, want)
+	}
+	if elapsed > time.Second {
+		t.Errorf("shutdown() took %v, want the stuck step cut off after its timeout", elapsed)
+	}
+
+	if !errors.Is(err, notifierErr) || !errors.Is(err, storageErr) {
+		t.Errorf("shutdown() error = %v, want the close errors joined", err)
+	}
+	for _, name := range []string{"closing browser did not finish", "failed to close notifier", "failed to close storage"} {
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("shutdown() error = %v, want it to mention %q", err, name)
+		}
+	}
+	if err != nil && strings.Contains(err.Error(), "parser") {
+		t.Errorf("shutdown() error = %v, want nothing about the parser that closed cleanly", err)
+	}
+}
+
+func TestShutdownWithoutErrors(t *testing.T) {
+	err := shutdown(context.Background(), time.Second,
+		shutdownStep{"browser", func() error { return nil }},
+		shutdownStep{"storage", func() error { return nil }},
+	)
+	if err != nil {
+		t.Errorf("shutdown() error = %v, want nil", err)
+	}
+}